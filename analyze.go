@@ -0,0 +1,314 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var (
+	emailPattern     = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)
+	ipv4Pattern      = regexp.MustCompile(`\b(?:\d{1,3}\.){3}\d{1,3}\b`)
+	ipv6Pattern      = regexp.MustCompile(`(?i)(?:[0-9a-f]{0,4}:){2,7}[0-9a-f]{0,4}`)
+	guidPattern      = regexp.MustCompile(`(?i)\b[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}\b`)
+	domainPattern    = regexp.MustCompile(`^(?:[a-z0-9](?:[a-z0-9\-]{0,61}[a-z0-9])?\.)+[a-z]{2,}$`)
+	gqlPattern       = regexp.MustCompile(`\b(query|mutation|fragment)\s+[_A-Za-z][_0-9A-Za-z]*`)
+	bucketPattern    = regexp.MustCompile(`(?i)(?:[a-z0-9.\-]+\.s3[.\-](?:[a-z0-9\-]+\.)?amazonaws\.com|s3[.\-](?:[a-z0-9\-]+\.)?amazonaws\.com/[a-z0-9.\-]+|storage\.googleapis\.com/[a-z0-9._\-]+|[a-z0-9.\-]+\.storage\.googleapis\.com|[a-z0-9\-]+\.blob\.core\.windows\.net|[a-z0-9.\-]+\.digitaloceanspaces\.com)`)
+	nodeModuleName   = regexp.MustCompile(`^(?:@[a-z0-9\-~][a-z0-9\-._~]*/)?[a-z0-9\-~][a-z0-9\-._~]*`)
+	jsFileExtensions = map[string]bool{".js": true, ".mjs": true, ".cjs": true, ".jsx": true}
+)
+
+// importedModulesField holds the packages a file imports. The server's
+// validNodeModules and invalidNodeModules are checked against the npm
+// registry, which the local analysis does not do, so imports get their own key.
+const importedModulesField = "importedModules"
+
+// analysisFields lists the fields analyzeJS fills in.
+var analysisFields = []string{
+	"extractedUrls", "extractedDomains", "ipv4Addresses", "ipv6Addresses", "emails",
+	"apiPaths", "gqlQuery", "gqlMutation", "gqlFragment", "s3Domains", importedModulesField,
+	"guids", "queryParamsUrls", "localhostUrls", "jsUrls",
+}
+
+// hasKnownTLD reports whether the last label of host is a top-level domain in
+// the public suffix list.
+func hasKnownTLD(host string) bool {
+	return loadPublicSuffixRules().exact[host[strings.LastIndex(host, ".")+1:]]
+}
+
+// gqlOperation returns the GraphQL operation starting at start in text, up to
+// its balanced closing brace, with whitespace collapsed.
+func gqlOperation(text string, start int) string {
+	depth, end := 0, len(text)
+	for i := start; i < len(text); i++ {
+		if text[i] == '{' {
+			depth++
+		} else if text[i] == '}' {
+			depth--
+			if depth == 0 {
+				end = i + 1
+				break
+			}
+		}
+	}
+	return strings.Join(strings.Fields(text[start:end]), " ")
+}
+
+// nodeModulePackage returns the package name of an import specifier, or ""
+// for relative and absolute paths.
+func nodeModulePackage(specifier string) string {
+	if specifier == "" || strings.HasPrefix(specifier, ".") || strings.HasPrefix(specifier, "/") || strings.Contains(specifier, ":") {
+		return ""
+	}
+	return nodeModuleName.FindString(specifier)
+}
+
+// analyzeJS extracts the categories the server's JS intelligence reports from
+// one JS source. It works on string and template literals, like the semantic
+// diff. Node modules are taken from require(), import and export ... from and
+// reported under importedModules.
+func analyzeJS(src string) IntelligenceResult {
+	values := map[string]map[string]bool{}
+	for _, field := range analysisFields {
+		values[field] = map[string]bool{}
+	}
+	add := func(field, value string) {
+		if value = strings.TrimSpace(value); value != "" {
+			values[field][value] = true
+		}
+	}
+	addURL := func(rawURL string) {
+		rawURL = strings.TrimRight(rawURL, ".,;)")
+		add("extractedUrls", rawURL)
+		host := normalizeHost(rawURL)
+		if host != "" {
+			add("extractedDomains", host)
+		}
+		if host == "localhost" || strings.HasPrefix(host, "127.") || host == "0.0.0.0" {
+			add("localhostUrls", rawURL)
+		}
+		if strings.Contains(rawURL, "?") {
+			add("queryParamsUrls", rawURL)
+		}
+		path := rawURL
+		if i := strings.IndexAny(path, "?#"); i >= 0 {
+			path = path[:i]
+		}
+		if strings.HasSuffix(strings.ToLower(path), ".js") {
+			add("jsUrls", rawURL)
+		}
+	}
+
+	tokens := tokenizeJS(src)
+	for i, token := range tokens {
+		if token.Kind != jsString && token.Kind != jsTemplate {
+			continue
+		}
+		text := token.Text
+		if token.Kind == jsTemplate {
+			text = templateSubst.ReplaceAllString(text, "{}")
+		}
+
+		// Import specifiers: require("x"), import("x"), import "x" and from "x".
+		if i > 0 {
+			prev := tokens[i-1]
+			isImport := prev.Kind == jsIdent && (prev.Value == "from" || prev.Value == "import")
+			if prev.Value == "(" && i > 1 && tokens[i-2].Kind == jsIdent && (tokens[i-2].Value == "require" || tokens[i-2].Value == "import") {
+				isImport = true
+			}
+			if isImport {
+				if pkg := nodeModulePackage(text); pkg != "" {
+					add(importedModulesField, pkg)
+				}
+				continue
+			}
+		}
+
+		for _, match := range urlPattern.FindAllString(text, -1) {
+			addURL(match)
+		}
+		if isAPIPath(text) {
+			add("apiPaths", text)
+		} else if j := strings.Index(text, "/"); j > 0 && token.Kind == jsTemplate && isAPIPath(text[j:]) {
+			add("apiPaths", text[j:])
+		}
+		if lower := strings.ToLower(strings.TrimSpace(text)); domainPattern.MatchString(lower) && hasKnownTLD(lower) {
+			add("extractedDomains", lower)
+		}
+		for _, match := range emailPattern.FindAllString(text, -1) {
+			if !staticAssetSuffix.MatchString(match) && hasKnownTLD(strings.ToLower(match)) {
+				add("emails", match)
+			}
+		}
+		for _, match := range ipv4Pattern.FindAllString(text, -1) {
+			if ip := net.ParseIP(match); ip != nil && ip.To4() != nil {
+				add("ipv4Addresses", match)
+			}
+		}
+		for _, match := range ipv6Pattern.FindAllString(text, -1) {
+			if ip := net.ParseIP(match); ip != nil && ip.To4() == nil {
+				add("ipv6Addresses", strings.ToLower(match))
+			}
+		}
+		for _, match := range guidPattern.FindAllString(text, -1) {
+			add("guids", strings.ToLower(match))
+		}
+		for _, match := range bucketPattern.FindAllString(text, -1) {
+			add("s3Domains", strings.ToLower(match))
+		}
+		for _, loc := range gqlPattern.FindAllStringSubmatchIndex(text, -1) {
+			operation := gqlOperation(text, loc[0])
+			if !strings.Contains(operation, "{") {
+				continue
+			}
+			switch text[loc[2]:loc[3]] {
+			case "query":
+				add("gqlQuery", operation)
+			case "mutation":
+				add("gqlMutation", operation)
+			case "fragment":
+				add("gqlFragment", operation)
+			}
+		}
+	}
+
+	list := func(field string) flexList {
+		var l flexList
+		for _, value := range sortedKeys(values[field]) {
+			raw, _ := json.Marshal(value)
+			l = append(l, raw)
+		}
+		return l
+	}
+	result := IntelligenceResult{
+		ExtractedUrls:    list("extractedUrls"),
+		ExtractedDomains: list("extractedDomains"),
+		Ipv4Addresses:    list("ipv4Addresses"),
+		Ipv6Addresses:    list("ipv6Addresses"),
+		Emails:           list("emails"),
+		ApiPaths:         list("apiPaths"),
+		GqlQuery:         list("gqlQuery"),
+		GqlMutation:      list("gqlMutation"),
+		GqlFragment:      list("gqlFragment"),
+		S3Domains:        list("s3Domains"),
+		Guids:            list("guids"),
+		QueryParamsUrls:  list("queryParamsUrls"),
+		LocalhostUrls:    list("localhostUrls"),
+		JsUrls:           list("jsUrls"),
+	}
+	if modules := list(importedModulesField); len(modules) > 0 {
+		raw, _ := json.Marshal(modules)
+		result.Extra = map[string]json.RawMessage{importedModulesField: raw}
+	}
+	return result
+}
+
+// localJSFiles returns path if it is a file, or the JS files under it if it is
+// a directory. node_modules and hidden directories are skipped.
+func localJSFiles(path string) ([]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading %s: %v", path, err)
+	}
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+	var files []string
+	err = filepath.Walk(path, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if p != path && (info.Name() == "node_modules" || strings.HasPrefix(info.Name(), ".")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if jsFileExtensions[strings.ToLower(filepath.Ext(p))] {
+			files = append(files, p)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error walking %s: %v", path, err)
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// analyzeLocalFiles analyzes every JS file under the given paths. The URL of
+// each result is the file path.
+func analyzeLocalFiles(paths []string) ([]IntelligenceResult, error) {
+	var results []IntelligenceResult
+	for _, path := range paths {
+		files, err := localJSFiles(path)
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range files {
+			data, err := os.ReadFile(file)
+			if err != nil {
+				return nil, fmt.Errorf("error reading %s: %v", file, err)
+			}
+			result := analyzeJS(string(data))
+			result.URL = file
+			results = append(results, result)
+		}
+	}
+	return results, nil
+}
+
+func runAnalyzeCommand(args []string) error {
+	fs := newCommandFlagSet("analyze", "analyze <file.js|dir>... [-show apis,emails] [-jsonl] [-o results.json]")
+	show := fs.String("show", "", "Comma-separated intelligence fields to list per file (e.g. apis,emails)")
+	jsonl := fs.Bool("jsonl", false, "Print one JSON result per line")
+	output := fs.String("o", "", "Write the results as a JSON array to this file")
+	positional, err := parseCommandArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) == 0 {
+		fs.Usage()
+		return fmt.Errorf("analyze needs a JS file or directory")
+	}
+	showFields, err := parseShowFields(*show)
+	if err != nil {
+		return err
+	}
+
+	results, err := analyzeLocalFiles(positional)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case *output != "":
+		if results == nil {
+			results = []IntelligenceResult{}
+		}
+		data, err := json.MarshalIndent(results, "", "  ")
+		if err != nil {
+			return fmt.Errorf("error formatting JSON: %v", err)
+		}
+		if err := os.WriteFile(*output, append(data, '\n'), 0644); err != nil {
+			return fmt.Errorf("error writing %s: %v", *output, err)
+		}
+		fmt.Printf("[INF] Wrote %d results to %s\n", len(results), *output)
+		return nil
+	case *jsonl:
+		for _, result := range results {
+			data, err := json.Marshal(result)
+			if err != nil {
+				return fmt.Errorf("error formatting JSON: %v", err)
+			}
+			fmt.Println(string(data))
+		}
+		return nil
+	}
+	return printIntelligenceResults(results, showFields)
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestAnalyzeJSImportedModules(t *testing.T) {
+	src := `import x from "lodash/fp";
+import "./local.css";
+const y = require("@scope/pkg/sub");
+export { z } from "react";
+fetch("/api/v1/users");`
+	result := analyzeJS(src)
+	if len(result.ValidNodeModules) != 0 {
+		t.Errorf("got validNodeModules %s, want none", result.ValidNodeModules.Strings())
+	}
+	data, err := json.Marshal(result)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `"importedModules":["@scope/pkg","lodash","react"]`
+	if !strings.Contains(string(data), want) {
+		t.Errorf("got %s, want it to contain %s", data, want)
+	}
+}
+
+const analyzeSample = `import { get } from "axios";
+const api = "https://api.example.com/v1/users?id=1";
+const cdn = "https://cdn.example.co.uk/static/app.js?v=3";
+const local = "http://localhost:8080/debug";
+const loop = "http://127.0.0.1:3000/health";
+const bare = "assets.example.org";
+const notDomain = "config.json";
+const ip4 = "10.0.0.12", badIp4 = "999.1.1.1";
+const ip6 = "2001:DB8::1", time = "12:30:45";
+const contact = "Security@Example.com", sprite = "logo@2x.png", unknownTld = "user@host.notatld";
+fetch("/api/v2/orders");
+fetch(` + "`${base}/graphql/items/${id}`" + `);
+const q = "query GetUser($id: ID!) { user(id: $id) { name } }";
+const m = "mutation  AddItem { add { id } }";
+const f = "fragment UserFields on User { id }";
+const notGql = "query string";
+const bucket = "https://my-bucket.s3.us-east-1.amazonaws.com/file.txt";
+const gcs = "storage.googleapis.com/my-gcs-bucket";
+const id = "3F2504E0-4F89-11D3-9A0C-0305E82C3301";
+`
+
+func TestAnalyzeJS(t *testing.T) {
+	result := analyzeJS(analyzeSample)
+	want := map[string][]string{
+		"extractedUrls": {
+			"http://127.0.0.1:3000/health",
+			"http://localhost:8080/debug",
+			"https://api.example.com/v1/users?id=1",
+			"https://cdn.example.co.uk/static/app.js?v=3",
+			"https://my-bucket.s3.us-east-1.amazonaws.com/file.txt",
+		},
+		"extractedDomains": {"127.0.0.1", "api.example.com", "assets.example.org", "cdn.example.co.uk", "localhost", "my-bucket.s3.us-east-1.amazonaws.com"},
+		"ipv4Addresses":    {"10.0.0.12", "127.0.0.1"},
+		"ipv6Addresses":    {"2001:db8::1"},
+		"emails":           {"Security@Example.com"},
+		"apiPaths":         {"/api/v2/orders", "/graphql/items/{}"},
+		"gqlQuery":         {"query GetUser($id: ID!) { user(id: $id) { name } }"},
+		"gqlMutation":      {"mutation AddItem { add { id } }"},
+		"gqlFragment":      {"fragment UserFields on User { id }"},
+		"s3Domains":        {"my-bucket.s3.us-east-1.amazonaws.com", "storage.googleapis.com/my-gcs-bucket"},
+		"guids":            {"3f2504e0-4f89-11d3-9a0c-0305e82c3301"},
+		"queryParamsUrls":  {"https://api.example.com/v1/users?id=1", "https://cdn.example.co.uk/static/app.js?v=3"},
+		"localhostUrls":    {"http://127.0.0.1:3000/health", "http://localhost:8080/debug"},
+		"jsUrls":           {"https://cdn.example.co.uk/static/app.js?v=3"},
+	}
+	for _, field := range analysisFields {
+		if field == importedModulesField {
+			var modules []string
+			if err := json.Unmarshal(result.Extra[field], &modules); err != nil || !reflect.DeepEqual(modules, []string{"axios"}) {
+				t.Errorf("%s: got %s", field, result.Extra[field])
+			}
+			continue
+		}
+		t.Run(field, func(t *testing.T) {
+			if got := result.Field(field).Strings(); !reflect.DeepEqual(got, want[field]) {
+				t.Errorf("got %q, want %q", got, want[field])
+			}
+		})
+	}
+}
+
+func TestLocalJSFiles(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{
+		"app.js", "lib/util.MJS", "lib/view.jsx", "lib/server.cjs", "style.css", "README.md",
+		"node_modules/react/index.js", "lib/node_modules/x/index.js", ".cache/bundle.js", "lib/.hidden/a.js",
+	} {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("var a;"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	files, err := localJSFiles(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, file := range files {
+		rel, _ := filepath.Rel(dir, file)
+		got = append(got, filepath.ToSlash(rel))
+	}
+	if want := []string{"app.js", "lib/server.cjs", "lib/util.MJS", "lib/view.jsx"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	// A file named directly is returned whatever its extension, and a hidden
+	// directory named directly is walked.
+	css := filepath.Join(dir, "style.css")
+	if files, err := localJSFiles(css); err != nil || !reflect.DeepEqual(files, []string{css}) {
+		t.Errorf("got %q, %v for a file", files, err)
+	}
+	if files, err := localJSFiles(filepath.Join(dir, ".cache")); err != nil || len(files) != 1 {
+		t.Errorf("got %q, %v for a hidden directory", files, err)
+	}
+	if _, err := localJSFiles(filepath.Join(dir, "missing")); err == nil {
+		t.Error("got no error for a missing path")
+	}
+}
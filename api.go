@@ -5,7 +5,6 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"log"
 	"mime/multipart"
 	"net/http"
 	"net/textproto"
@@ -26,8 +25,9 @@ type DataItem struct {
 }
 
 type DetectedWord struct {
-	Name  string   `json:"name"`
-	Words []string `json:"words"`
+	Name          string             `json:"name"`
+	Words         []string           `json:"words"`
+	Verifications []wordVerification `json:"verifications,omitempty"`
 }
 
 type DomainResponse struct {
@@ -46,114 +46,91 @@ type URLEntry struct {
 }
 
 
-func uploadFileEndpoint(filePath string, headers []string, wkspId string) {
-    endpoint := fmt.Sprintf("%s/uploadFile?wkspId=%s", apiBaseURL, wkspId)
-    
-    if len(headers) > 0 {
-        headersJSON, err := json.Marshal(headers)
-        if err != nil {
-            log.Fatalf("Error marshaling headers: %v", err)
-        }
-        endpoint = fmt.Sprintf("%s&headers=%s", endpoint, string(headersJSON))
-    }
+func uploadFileEndpoint(filePath string, headers []string, wkspId string) error {
+	endpoint := fmt.Sprintf("%s/uploadFile?wkspId=%s", apiBaseURL, wkspId)
 
-    file, err := os.Open(filePath)
-    if err != nil {
-        log.Fatalf("Error opening file: %v", err)
-    }
-    defer file.Close()
+	if len(headers) > 0 {
+		headersJSON, err := json.Marshal(headers)
+		if err != nil {
+			return fmt.Errorf("error marshaling headers: %v", err)
+		}
+		endpoint = fmt.Sprintf("%s&headers=%s", endpoint, string(headersJSON))
+	}
 
-    // Read and validate file content
-    content, err := io.ReadAll(file)
-    if err != nil {
-        log.Fatalf("Error reading file: %v", err)
-    }
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return fmt.Errorf("error reading file: %v", err)
+	}
 
-    // Check if content is valid UTF-8
-    if !utf8.Valid(content) {
-        log.Fatalf("File content is not valid UTF-8")
-    }
+	// Check if content is valid UTF-8
+	if !utf8.Valid(content) {
+		return fmt.Errorf("file content is not valid UTF-8")
+	}
 
-    // Count lines and validate URLs
-    lines := strings.Split(string(content), "\n")
-    validURLCount := 0
-    for _, line := range lines {
-        line = strings.TrimSpace(line)
-        if line != "" && strings.HasPrefix(line, "http") {
-            validURLCount++
-        }
-    }
-    fmt.Printf("Found %d valid URLs in file\n", validURLCount)
+	// Count lines and validate URLs
+	lines := strings.Split(string(content), "\n")
+	validURLCount := 0
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if line != "" && strings.HasPrefix(line, "http") {
+			validURLCount++
+		}
+	}
+	fmt.Printf("Found %d valid URLs in file\n", validURLCount)
 
-    if validURLCount == 0 {
-        log.Fatalf("No valid URLs found in file")
-    }
-    if validURLCount > 1000 {
-        log.Fatalf("Too many URLs in file (max 1000)")
-    }
+	if validURLCount == 0 {
+		return fmt.Errorf("no valid URLs found in file")
+	}
+	if validURLCount > 1000 {
+		return fmt.Errorf("too many URLs in file (max 1000)")
+	}
 
-    // Reset file pointer
-    file.Seek(0, 0)
-
-    // Create multipart form
-    var requestBody bytes.Buffer
-    writer := multipart.NewWriter(&requestBody)
-
-    // Create form file part with explicit Content-Type
-    h := make(textproto.MIMEHeader)
-    h.Set("Content-Disposition", fmt.Sprintf(`form-data; name="file"; filename="%s"`, filepath.Base(filePath)))
-    h.Set("Content-Type", "text/plain; charset=utf-8")
-    
-    part, err := writer.CreatePart(h)
-    if err != nil {
-        log.Fatalf("Error creating form file: %v", err)
-    }
+	// Create multipart form
+	var requestBody bytes.Buffer
+	writer := multipart.NewWriter(&requestBody)
 
-    _, err = io.Copy(part, bytes.NewReader(content))
-    if err != nil {
-        log.Fatalf("Error copying file data: %v", err)
-    }
+	// Create form file part with explicit Content-Type
+	h := make(textproto.MIMEHeader)
+	h.Set("Content-Disposition", fmt.Sprintf(`form-data; name="file"; filename="%s"`, filepath.Base(filePath)))
+	h.Set("Content-Type", "text/plain; charset=utf-8")
 
-    err = writer.Close()
-    if err != nil {
-        log.Fatalf("Error closing writer: %v", err)
-    }
+	part, err := writer.CreatePart(h)
+	if err != nil {
+		return fmt.Errorf("error creating form file: %v", err)
+	}
+	if _, err := part.Write(content); err != nil {
+		return fmt.Errorf("error copying file data: %v", err)
+	}
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("error closing writer: %v", err)
+	}
 
-    // Build HTTP request
-    req, err := http.NewRequest("POST", endpoint, &requestBody)
-    if err != nil {
-        log.Fatalf("Error creating HTTP request: %v", err)
-    }
+	req, err := http.NewRequest("POST", endpoint, &requestBody)
+	if err != nil {
+		return fmt.Errorf("error creating HTTP request: %v", err)
+	}
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	req.Header.Set("X-Jsmon-Key", strings.TrimSpace(getAPIKey()))
 
-    // Set required headers
-    req.Header.Set("Content-Type", writer.FormDataContentType())
-    req.Header.Set("X-Jsmon-Key", strings.TrimSpace(getAPIKey()))
-
-    // Debug output
-    // fmt.Printf("Sending request to: %s\n", endpoint)
-    // fmt.Printf("Content-Type: %s\n", writer.FormDataContentType())
-    // fmt.Printf("File size: %d bytes\n", len(content))
-    // fmt.Printf("API Key: %s\n", maskAPIKey(strings.TrimSpace(getAPIKey())))
-
-    // Execute request
-    client := &http.Client{}
-    resp, err := client.Do(req)
-    if err != nil {
-        log.Fatalf("Upload request failed: %v", err)
-    }
-    defer resp.Body.Close()
-
-    // Read and handle response
-    bodyBytes, _ := io.ReadAll(resp.Body)
-    
-    if resp.StatusCode == http.StatusOK {
-        fmt.Println("File uploaded successfully!")
-        fmt.Println("Response:", string(bodyBytes))
-    } else {
-        fmt.Printf("Upload failed with status code: %d\n", resp.StatusCode)
-        // fmt.Printf("Response headers: %v\n", resp.Header)
-        // fmt.Println("Response body:", string(bodyBytes))
-    }
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("upload request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	bodyBytes, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("error reading response: %v", err)
+	}
+	if resp.StatusCode == http.StatusOK {
+		fmt.Println("File uploaded successfully!")
+		fmt.Println("Response:", string(bodyBytes))
+		return nil
+	} else if resp.StatusCode == 401 {
+		return fmt.Errorf("wrong API key")
+	}
+	return fmt.Errorf("upload failed with status code %d", resp.StatusCode)
 }
 
 // Helper function to mask API key for logging
@@ -204,10 +181,12 @@ func automateScanDomain(domain string, words []string, wkspId string) error {
 
 	if resp.StatusCode == 200 {
 		fmt.Printf("[INF] %s scanned successfully\n", domain)
+		return nil
 	} else if resp.StatusCode == 401 {
-		fmt.Printf("[ERR] Wrong API Key\n")
-	} else {
-		fmt.Printf("[INF] %s, error in scanning\n", domain)
+		return fmt.Errorf("wrong API key")
+	}
+	if message, ok := response["message"].(string); ok && message != "" {
+		return fmt.Errorf("error in scanning %s: %s", domain, message)
 	}
-	return nil
+	return fmt.Errorf("error in scanning %s: status code %d", domain, resp.StatusCode)
 }
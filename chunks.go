@@ -0,0 +1,569 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// chunkExpr evaluates the expressions the webpack runtime uses to build chunk
+// file names, such as
+//
+//	"static/js/" + ({} [e] || e) + "." + {12: "a1b2"}[e] + ".chunk.js"
+//
+// for one chunk ID. Only string concatenation, ||, parentheses, lookups in
+// object literals, the public path (x.p) and conditionals comparing the chunk
+// ID with a literal are understood.
+type chunkExpr struct {
+	param      string
+	id         string
+	publicPath string
+	// usedPublicPath is set when the expression includes the public path.
+	usedPublicPath bool
+}
+
+// splitTopLevel splits tokens at the given punctuator outside brackets.
+func splitTopLevel(tokens []jsToken, sep string) [][]jsToken {
+	var parts [][]jsToken
+	depth, start := 0, 0
+	for i, t := range tokens {
+		if t.Kind != jsPunct {
+			continue
+		}
+		switch t.Value {
+		case "(", "[", "{":
+			depth++
+		case ")", "]", "}":
+			depth--
+		case sep:
+			if depth == 0 {
+				parts = append(parts, tokens[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, tokens[start:])
+}
+
+// parseStringMap parses an object literal whose values are all strings, such
+// as the chunk hash map {12:"a1b2",34:"c3d4"}. tokens must span the braces.
+func parseStringMap(tokens []jsToken) (map[string]string, bool) {
+	if len(tokens) < 2 || !isPunct(tokens, 0, "{") || !isPunct(tokens, len(tokens)-1, "}") {
+		return nil, false
+	}
+	values := map[string]string{}
+	inner := tokens[1 : len(tokens)-1]
+	if len(inner) == 0 {
+		return values, true
+	}
+	for _, entry := range splitTopLevel(inner, ",") {
+		if len(entry) == 0 {
+			continue
+		}
+		if len(entry) != 3 || !isPunct(entry, 1, ":") || entry[2].Kind != jsString {
+			return nil, false
+		}
+		key := entry[0].Value
+		switch entry[0].Kind {
+		case jsString:
+			key = entry[0].Text
+		case jsNumber, jsIdent:
+		default:
+			return nil, false
+		}
+		values[key] = entry[2].Text
+	}
+	return values, true
+}
+
+// mapLookup matches {...}[param] or ({...})[param] and returns the map and
+// the parameter.
+func mapLookup(tokens []jsToken) (map[string]string, string, bool) {
+	n := len(tokens)
+	if n < 5 || !isPunct(tokens, n-3, "[") || tokens[n-2].Kind != jsIdent || !isPunct(tokens, n-1, "]") {
+		return nil, "", false
+	}
+	object := tokens[:n-3]
+	if isPunct(object, 0, "(") && isPunct(object, len(object)-1, ")") {
+		object = object[1 : len(object)-1]
+	}
+	values, ok := parseStringMap(object)
+	return values, tokens[n-2].Value, ok
+}
+
+func (c *chunkExpr) eval(tokens []jsToken) (string, bool) {
+	if branches := splitTopLevel(tokens, "?"); len(branches) > 1 {
+		rest := tokens[len(branches[0])+1:]
+		alternatives := splitTopLevel(rest, ":")
+		if len(alternatives) < 2 {
+			return "", false
+		}
+		match, ok := c.matchesID(branches[0])
+		if !ok {
+			return "", false
+		}
+		if match {
+			return c.eval(alternatives[0])
+		}
+		return c.eval(rest[len(alternatives[0])+1:])
+	}
+	if alternatives := splitTopLevel(tokens, "||"); len(alternatives) > 1 {
+		for _, alternative := range alternatives {
+			if value, ok := c.eval(alternative); ok && value != "" {
+				return value, true
+			}
+		}
+		return "", false
+	}
+	terms := splitTopLevel(tokens, "+")
+	if len(terms) > 1 {
+		var b strings.Builder
+		for _, term := range terms {
+			value, ok := c.eval(term)
+			if !ok {
+				return "", false
+			}
+			b.WriteString(value)
+		}
+		return b.String(), true
+	}
+
+	switch {
+	case len(tokens) == 1 && tokens[0].Kind == jsString:
+		return tokens[0].Text, true
+	case len(tokens) == 1 && tokens[0].Kind == jsNumber:
+		return tokens[0].Value, true
+	case len(tokens) == 1 && tokens[0].Kind == jsTemplate:
+		return c.evalTemplate(tokens[0].Value)
+	case len(tokens) == 1 && tokens[0].Kind == jsIdent && tokens[0].Value == c.param:
+		return c.id, true
+	case len(tokens) == 3 && tokens[0].Kind == jsIdent && isPunct(tokens, 1, ".") && tokens[2].Value == "p":
+		c.usedPublicPath = true
+		return c.publicPath, true
+	case len(tokens) > 2 && isPunct(tokens, 0, "(") && matchingBracket(tokens, 0) == len(tokens)-1:
+		return c.eval(tokens[1 : len(tokens)-1])
+	}
+	if values, param, ok := mapLookup(tokens); ok && param == c.param {
+		value, found := values[c.id]
+		return value, found
+	}
+	return "", false
+}
+
+// comparedID matches a comparison of param with a literal, such as
+// 2962===e, and returns the literal.
+func comparedID(tokens []jsToken, param string) (id string, negate, ok bool) {
+	if len(tokens) != 3 || tokens[1].Kind != jsPunct {
+		return "", false, false
+	}
+	switch tokens[1].Value {
+	case "===", "==":
+	case "!==", "!=":
+		negate = true
+	default:
+		return "", false, false
+	}
+	literal := tokens[0]
+	if literal.Kind == jsIdent && literal.Value == param {
+		literal = tokens[2]
+	} else if tokens[2].Kind != jsIdent || tokens[2].Value != param {
+		return "", false, false
+	}
+	switch literal.Kind {
+	case jsString:
+		return literal.Text, negate, true
+	case jsNumber:
+		return literal.Value, negate, true
+	}
+	return "", false, false
+}
+
+// matchesID evaluates a comparison of the chunk ID with a literal.
+func (c *chunkExpr) matchesID(tokens []jsToken) (bool, bool) {
+	id, negate, ok := comparedID(tokens, c.param)
+	return ok && (id == c.id) != negate, ok
+}
+
+// evalTemplate evaluates a template literal, given with its backquotes, whose
+// substitutions are themselves chunk expressions.
+func (c *chunkExpr) evalTemplate(raw string) (string, bool) {
+	raw = strings.TrimSuffix(strings.TrimPrefix(raw, "`"), "`")
+	var b strings.Builder
+	for {
+		start := strings.Index(raw, "${")
+		if start < 0 {
+			b.WriteString(raw)
+			return b.String(), true
+		}
+		b.WriteString(raw[:start])
+		depth, end := 0, -1
+		for i := start + 1; i < len(raw); i++ {
+			if raw[i] == '{' {
+				depth++
+			} else if raw[i] == '}' {
+				depth--
+				if depth == 0 {
+					end = i
+					break
+				}
+			}
+		}
+		if end < 0 {
+			return "", false
+		}
+		value, ok := c.eval(tokenizeJS(raw[start+2 : end]))
+		if !ok {
+			return "", false
+		}
+		b.WriteString(value)
+		raw = raw[end+1:]
+	}
+}
+
+// expressionStart walks left from tokens[i] to the start of the expression
+// containing it. It stops at return, =>, = and statement or list boundaries,
+// stepping out of grouping parentheses but not call parentheses. groups is
+// the number of grouping parentheses stepped out of.
+func expressionStart(tokens []jsToken, i int) (start, groups int) {
+	depth := 0
+	for j := i - 1; j >= 0; j-- {
+		t := tokens[j]
+		if t.Kind == jsIdent && t.Value == "return" {
+			return j + 1, groups
+		}
+		if t.Kind != jsPunct {
+			continue
+		}
+		switch t.Value {
+		case ")", "]", "}":
+			depth++
+		case "(", "[", "{":
+			if depth > 0 {
+				depth--
+				continue
+			}
+			if t.Value == "(" && (j == 0 || tokens[j-1].Kind != jsIdent && !isPunct(tokens, j-1, ")") && !isPunct(tokens, j-1, "]")) {
+				groups++
+				continue
+			}
+			return j + 1, groups
+		case "=>", "=", ";", ",", "?", ":":
+			if depth == 0 {
+				return j + 1, groups
+			}
+		}
+	}
+	return 0, groups
+}
+
+// expressionEnd walks right from tokens[i] to the end of the expression,
+// closing the given number of grouping parentheses on the way.
+func expressionEnd(tokens []jsToken, i, groups int) int {
+	depth, conditionals := 0, 0
+	for j := i; j < len(tokens); j++ {
+		t := tokens[j]
+		if t.Kind != jsPunct {
+			continue
+		}
+		switch t.Value {
+		case "(", "[", "{":
+			depth++
+		case ")", "]", "}":
+			if depth > 0 {
+				depth--
+				continue
+			}
+			if t.Value == ")" && groups > 0 {
+				groups--
+				continue
+			}
+			return j
+		case "?":
+			if depth == 0 {
+				conditionals++
+			}
+		case ":":
+			if depth == 0 && conditionals == 0 {
+				return j
+			}
+			if depth == 0 {
+				conditionals--
+			}
+		case ";", ",":
+			if depth == 0 {
+				return j
+			}
+		}
+	}
+	return len(tokens)
+}
+
+// webpackChunkFiles finds the chunk file name expressions of a webpack runtime
+// (__webpack_require__.u in webpack 5, the jsonp script src function in
+// webpack 4) and evaluates them for every chunk ID found in the hash maps and
+// in __webpack_require__.e(id) calls, prefixed with the public path. A
+// non-empty publicPath replaces the one the runtime sets.
+func webpackChunkFiles(src, publicPath string) []string {
+	var tokens []jsToken
+	for _, t := range tokenizeJS(src) {
+		if t.Kind != jsComment {
+			tokens = append(tokens, t)
+		}
+	}
+
+	override := publicPath != ""
+	ids := map[string]bool{}
+	type candidate struct {
+		param string
+		expr  []jsToken
+	}
+	var candidates []candidate
+	for i := 1; i+2 < len(tokens); i++ {
+		if !isPunct(tokens, i, ".") || tokens[i+1].Kind != jsIdent {
+			continue
+		}
+		switch {
+		case !override && tokens[i+1].Value == "p" && isPunct(tokens, i+2, "=") && i+3 < len(tokens) && tokens[i+3].Kind == jsString:
+			publicPath = tokens[i+3].Text
+		case tokens[i+1].Value == "e" && isPunct(tokens, i+2, "(") && i+4 < len(tokens) && isPunct(tokens, i+4, ")"):
+			if id := tokens[i+3]; id.Kind == jsNumber {
+				ids[id.Value] = true
+			} else if id.Kind == jsString {
+				ids[id.Text] = true
+			}
+		case tokens[i+1].Value == "u" && isPunct(tokens, i+2, "="):
+			// __webpack_require__.u = e => expr, (e) => expr or
+			// function(e) { return expr }.
+			j := i + 3
+			param := ""
+			switch {
+			case j+1 < len(tokens) && tokens[j].Kind == jsIdent && isPunct(tokens, j+1, "=>"):
+				param, j = tokens[j].Value, j+2
+			case j+3 < len(tokens) && isPunct(tokens, j, "(") && tokens[j+1].Kind == jsIdent && isPunct(tokens, j+2, ")") && isPunct(tokens, j+3, "=>"):
+				param, j = tokens[j+1].Value, j+4
+			case j+6 < len(tokens) && tokens[j].Value == "function" && isPunct(tokens, j+1, "(") && tokens[j+2].Kind == jsIdent &&
+				isPunct(tokens, j+3, ")") && isPunct(tokens, j+4, "{") && tokens[j+5].Value == "return":
+				param, j = tokens[j+2].Value, j+6
+			default:
+				continue
+			}
+			if isPunct(tokens, j, "{") {
+				continue
+			}
+			candidates = append(candidates, candidate{param, tokens[j:expressionEnd(tokens, j, 0)]})
+		}
+	}
+
+	// Lookup maps anywhere else are chunk name or hash maps if they sit in a
+	// concatenation, as in webpack 4's jsonpScriptSrc.
+	for i := range tokens {
+		if !isPunct(tokens, i, "{") {
+			continue
+		}
+		closing := matchingBracket(tokens, i)
+		if closing < 0 {
+			continue
+		}
+		start, next := i, closing+1
+		if isPunct(tokens, next, ")") && isPunct(tokens, i-1, "(") {
+			start, next = i-1, next+1
+		}
+		if !isPunct(tokens, next, "[") || next+2 >= len(tokens) || tokens[next+1].Kind != jsIdent || !isPunct(tokens, next+2, "]") {
+			continue
+		}
+		values, ok := parseStringMap(tokens[i : closing+1])
+		if !ok {
+			continue
+		}
+		start, groups := expressionStart(tokens, start)
+		expr := tokens[start:expressionEnd(tokens, next+3, groups)]
+		if len(splitTopLevel(expr, "+")) > 1 {
+			candidates = append(candidates, candidate{tokens[next+1].Value, expr})
+			for id := range values {
+				ids[id] = true
+			}
+		}
+	}
+
+	for _, c := range candidates {
+		for k := 1; k+1 < len(c.expr); k++ {
+			if id, _, ok := comparedID(c.expr[k-1:k+2], c.param); ok {
+				ids[id] = true
+			}
+		}
+	}
+
+	seen := map[string]bool{}
+	var files []string
+	for _, c := range candidates {
+		for _, id := range sortedKeys(ids) {
+			e := &chunkExpr{param: c.param, id: id, publicPath: publicPath}
+			name, ok := e.eval(c.expr)
+			if !ok || !isJSChunk(name) {
+				continue
+			}
+			if !e.usedPublicPath {
+				name = publicPath + name
+			}
+			if !seen[name] {
+				seen[name] = true
+				files = append(files, name)
+			}
+		}
+	}
+	return files
+}
+
+func isJSChunk(name string) bool {
+	if i := strings.IndexAny(name, "?#"); i >= 0 {
+		name = name[:i]
+	}
+	return strings.HasSuffix(name, ".js")
+}
+
+// nextBuildManifestFiles returns the chunk files listed in a Next.js
+// _buildManifest.js, relative to the /_next/ directory.
+func nextBuildManifestFiles(src string) []string {
+	seen := map[string]bool{}
+	var files []string
+	for _, t := range tokenizeJS(src) {
+		if t.Kind == jsString && strings.HasPrefix(t.Text, "static/") && isJSChunk(t.Text) && !seen[t.Text] {
+			seen[t.Text] = true
+			files = append(files, t.Text)
+		}
+	}
+	return files
+}
+
+// discoverChunkURLs returns the chunk URLs referenced by the webpack runtime or
+// Next.js build manifest at ref. base replaces the public path of the bundle;
+// chunk names are otherwise resolved against ref, so they stay relative paths
+// for a local file.
+func discoverChunkURLs(fetcher jsFetcher, ref, base string) ([]string, error) {
+	resource, err := fetcher.fetch(ref)
+	if err != nil {
+		return nil, err
+	}
+	src := string(resource.Body)
+
+	var files []string
+	if strings.Contains(src, "__BUILD_MANIFEST") {
+		if base == "" {
+			base = "/_next/"
+			if i := strings.Index(ref, "/_next/"); i >= 0 && isHTTPRef(ref) {
+				base = ref[:i+len("/_next/")]
+			}
+		}
+		for _, file := range nextBuildManifestFiles(src) {
+			files = append(files, base+file)
+		}
+	} else {
+		files = webpackChunkFiles(src, base)
+	}
+
+	var urls []string
+	for _, file := range files {
+		if !isHTTPRef(ref) && !isHTTPRef(file) {
+			urls = append(urls, file)
+			continue
+		}
+		resolved, err := resolveJSRef(ref, file)
+		if err != nil {
+			return nil, err
+		}
+		urls = append(urls, resolved)
+	}
+	return urls, nil
+}
+
+func runChunksCommand(args []string) error {
+	fs := newCommandFlagSet("chunks", "chunks <main.js|url|_buildManifest.js>... [-base https://host/path/] [-o urls.txt] [-upload -wksp <id>]")
+	base := fs.String("base", "", "Public path to build chunk URLs from (e.g. https://example.com/_next/), overriding the bundle's")
+	output := fs.String("o", "", "Write the chunk URLs to this file, one per line")
+	upload := fs.Bool("upload", false, "Upload the chunk URLs for scanning")
+	wkspId := fs.String("wksp", *workspaceFlag, "Workspace ID for -upload")
+	var headerFlags stringSliceFlag
+	fs.Var(&headerFlags, "H", "Header for fetching and scanning the JS in the format 'Key: Value' (can be used multiple times)")
+	positional, err := parseCommandArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) == 0 {
+		fs.Usage()
+		return fmt.Errorf("chunks needs a JS file or URL")
+	}
+	headerList := append(append([]string{}, headers...), headerFlags...)
+	if *upload {
+		if err := ensureAPIKey(); err != nil {
+			return err
+		}
+		if err := requireWorkspace(*wkspId); err != nil {
+			return err
+		}
+	}
+
+	fetcher := newJSFetcher(headerList)
+	seen := map[string]bool{}
+	var urls []string
+	for _, input := range positional {
+		found, err := discoverChunkURLs(fetcher, input, *base)
+		if err != nil {
+			return err
+		}
+		if len(found) == 0 {
+			fmt.Fprintf(os.Stderr, "[WRN] %s: no chunk references found\n", input)
+		}
+		for _, u := range found {
+			if !seen[u] {
+				seen[u] = true
+				urls = append(urls, u)
+			}
+		}
+	}
+	sort.Strings(urls)
+
+	if *output == "" && !*upload {
+		for _, u := range urls {
+			fmt.Println(u)
+		}
+		return nil
+	}
+	if *output != "" && len(urls) == 0 {
+		fmt.Printf("[INF] No chunk URLs found, %s not written\n", *output)
+	} else if *output != "" {
+		if err := os.WriteFile(*output, []byte(strings.Join(urls, "\n")+"\n"), 0644); err != nil {
+			return fmt.Errorf("error writing %s: %v", *output, err)
+		}
+		fmt.Printf("[INF] Wrote %d chunk URLs to %s\n", len(urls), *output)
+	}
+	if !*upload {
+		return nil
+	}
+
+	var uploadable []string
+	for _, u := range urls {
+		if isHTTPRef(u) {
+			uploadable = append(uploadable, u)
+		} else {
+			fmt.Fprintf(os.Stderr, "[WRN] Skipping %s: not a URL, set -base to upload it\n", u)
+		}
+	}
+	if len(uploadable) == 0 {
+		return fmt.Errorf("no chunk URLs to upload")
+	}
+	file, err := os.CreateTemp("", "jsmon-chunks-*.txt")
+	if err != nil {
+		return fmt.Errorf("error creating temporary file: %v", err)
+	}
+	defer os.Remove(file.Name())
+	_, err = file.WriteString(strings.Join(uploadable, "\n") + "\n")
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("error writing %s: %v", file.Name(), err)
+	}
+	fmt.Printf("[INF] Uploading %d chunk URLs\n", len(uploadable))
+	return uploadFileEndpoint(file.Name(), headerList, *wkspId)
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestDiscoverChunkURLs(t *testing.T) {
+	fetcher := stubJSFetcher{
+		"https://x.com/_next/static/chunks/webpack-abc.js": {Body: []byte(`!function(){"use strict";var d={};d.u=function(e){return 2962===e?"static/chunks/2962-0aa.js":"static/chunks/"+(({261:"reactPlayerTwitch",2546:"reactPlayerStreamable"})[e]||e)+"."+({261:"aa11",2546:"bb22",7717:"cc33"})[e]+".js"},d.p="/_next/"}();`)},
+		"tpl.js":                   {Body: []byte("var n={};n.u=e=>`chunks/${e}.js`;n.p=\"\";n.e(5).then(()=>n.e(\"vendor\"));")},
+		"https://x.com/w4.js":      {Body: []byte(`!function(e){function a(e){return f.p+"static/js/"+({0:"vendors~admin"}[e]||e)+"."+{0:"111",1:"222",2:"333"}[e]+".chunk.js"}var f=function(){};f.p="https://cdn.example.com/";f.e=function(e){var s=document.createElement("script");s.src=a(e)}}([]);`)},
+		"https://x.com/ternary.js": {Body: []byte(`var n={};n.u=e=>"static/js/"+(e===12?"admin":"page-"+e)+".js";n.p="/";n.e(12);n.e(3);`)},
+		"https://x.com/_next/static/BUILD1/_buildManifest.js": {Body: []byte(`self.__BUILD_MANIFEST=function(s,c,a){return{__rewrites:{beforeFiles:[],afterFiles:[],fallback:[]},"/":[s,"static/chunks/pages/index-5f4d.js"],"/about":[s,c,"static/chunks/pages/about-9a8b.js"],"/_error":["static/chunks/pages/_error-77.js"],sortedPages:["/","/_app","/_error","/about"]}}("static/chunks/1234-aaa.js","static/css/x.css",1),self.__BUILD_MANIFEST_CB&&self.__BUILD_MANIFEST_CB();`)},
+		"https://x.com/plain.js":                              {Body: []byte(`fetch("/api/x");`)},
+	}
+	tests := []struct {
+		name string
+		ref  string
+		base string
+		want []string
+	}{
+		{"webpack5 u with ternary", "https://x.com/_next/static/chunks/webpack-abc.js", "", []string{
+			"https://x.com/_next/static/chunks/2962-0aa.js",
+			"https://x.com/_next/static/chunks/7717.cc33.js",
+			"https://x.com/_next/static/chunks/reactPlayerStreamable.bb22.js",
+			"https://x.com/_next/static/chunks/reactPlayerTwitch.aa11.js",
+		}},
+		{"webpack5 template on a local file", "tpl.js", "", []string{"chunks/5.js", "chunks/vendor.js"}},
+		{"webpack5 base override", "tpl.js", "https://cdn.x.com/assets/", []string{
+			"https://cdn.x.com/assets/chunks/5.js",
+			"https://cdn.x.com/assets/chunks/vendor.js",
+		}},
+		{"webpack4 jsonpScriptSrc", "https://x.com/w4.js", "", []string{
+			"https://cdn.example.com/static/js/1.222.chunk.js",
+			"https://cdn.example.com/static/js/2.333.chunk.js",
+			"https://cdn.example.com/static/js/vendors~admin.111.chunk.js",
+		}},
+		{"ternary chunk name", "https://x.com/ternary.js", "", []string{
+			"https://x.com/static/js/admin.js",
+			"https://x.com/static/js/page-3.js",
+		}},
+		{"next build manifest", "https://x.com/_next/static/BUILD1/_buildManifest.js", "", []string{
+			"https://x.com/_next/static/chunks/1234-aaa.js",
+			"https://x.com/_next/static/chunks/pages/_error-77.js",
+			"https://x.com/_next/static/chunks/pages/about-9a8b.js",
+			"https://x.com/_next/static/chunks/pages/index-5f4d.js",
+		}},
+		{"next build manifest with base", "https://x.com/_next/static/BUILD1/_buildManifest.js", "https://cdn.x.com/_next/", []string{
+			"https://cdn.x.com/_next/static/chunks/1234-aaa.js",
+			"https://cdn.x.com/_next/static/chunks/pages/_error-77.js",
+			"https://cdn.x.com/_next/static/chunks/pages/about-9a8b.js",
+			"https://cdn.x.com/_next/static/chunks/pages/index-5f4d.js",
+		}},
+		{"no chunks", "https://x.com/plain.js", "", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := discoverChunkURLs(fetcher, tt.ref, tt.base)
+			if err != nil {
+				t.Fatal(err)
+			}
+			sort.Strings(got)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := discoverChunkURLs(fetcher, "https://x.com/missing.js", ""); err == nil || !strings.Contains(err.Error(), "404") {
+		t.Errorf("got error %v for a missing file", err)
+	}
+}
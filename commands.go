@@ -0,0 +1,106 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+)
+
+// subcommands maps the first positional argument (e.g. "jsmon cron start") to
+// its handler. Each handler parses its own flags from args.
+var subcommands = map[string]func(args []string) error{
+	"analyze":    runAnalyzeCommand,
+	"chunks":     runChunksCommand,
+	"cron":       runCronCommand,
+	"diff":       runDiffCommand,
+	"extract":    runExtractCommand,
+	"graph":      runGraphCommand,
+	"history":    runHistoryCommand,
+	"jwt":        runJWTCommand,
+	"query":      runQueryCommand,
+	"rsearch":    runRsearchCommand,
+	"scan-local": runScanLocalCommand,
+	"secrets":    runSecretsCommand,
+	"sourcemap":  runSourceMapCommand,
+	"unpack":     runUnpackCommand,
+}
+
+// offlineCommands are subcommands that run without an API key. Those with an
+// optional API mode, such as chunks -upload or jwt without tokens, call
+// ensureAPIKey before using it.
+var offlineCommands = map[string]bool{
+	"analyze":    true,
+	"chunks":     true,
+	"jwt":        true,
+	"scan-local": true,
+	"sourcemap":  true,
+	"unpack":     true,
+}
+
+// runSubcommand dispatches to a registered subcommand. A -h/-help request is
+// not treated as an error.
+func runSubcommand(name string, args []string) error {
+	run, ok := subcommands[name]
+	if !ok {
+		return fmt.Errorf("unknown command %q", name)
+	}
+	err := run(args)
+	if errors.Is(err, flag.ErrHelp) {
+		return nil
+	}
+	return err
+}
+
+// newCommandFlagSet creates a flag set for a subcommand whose usage lists the
+// command's flags.
+func newCommandFlagSet(name, usage string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(os.Stdout)
+	fs.Usage = func() {
+		fmt.Printf("Usage: jsmon %s\n", usage)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parseCommandArgs parses flags that may appear before, between or after
+// positional arguments and returns the positional arguments in order.
+func parseCommandArgs(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+// ensureAPIKey loads the saved API key for offline commands that call the API
+// for some of their flags.
+func ensureAPIKey() error {
+	if getAPIKey() != "" {
+		return nil
+	}
+	if err := loadAPIKey(); err != nil {
+		return fmt.Errorf("error loading API key: %v, provide one with -key", err)
+	}
+	return nil
+}
+
+// requireWorkspace reports a missing -wksp the same way the top-level flags do.
+func requireWorkspace(wkspId string) error {
+	if wkspId != "" {
+		return nil
+	}
+	fmt.Println("No workspace specified. Use -workspaces to list available workspaces and provide a workspace ID using the -wksp flag.")
+	if err := displayWorkspaces(); err != nil {
+		fmt.Printf("Error listing workspaces: %v\n", err)
+	}
+	return fmt.Errorf("no workspace specified")
+}
@@ -7,7 +7,6 @@ import (
 	"io"
 	"net/http"
 	"strings"
-	// "time"
 )
 
 type DiffItem struct {
@@ -16,7 +15,9 @@ type DiffItem struct {
 	Value   string `json:"value"`
 }
 
-func compareEndpoint(id1, id2 string, wkspId string) {
+// compareEndpoint returns the line diff between two stored JS versions.
+// Unchanged chunks are included so callers can show context.
+func compareEndpoint(id1, id2 string, wkspId string) ([]DiffItem, error) {
 	endpoint := fmt.Sprintf("%s/compare?wkspId=%s", apiBaseURL, wkspId)
 
 	requestBody, err := json.Marshal(map[string]string{
@@ -24,14 +25,12 @@ func compareEndpoint(id1, id2 string, wkspId string) {
 		"id2": id2,
 	})
 	if err != nil {
-		fmt.Println("Error creating request body:", err)
-		return
+		return nil, fmt.Errorf("error creating request body: %v", err)
 	}
 
 	req, err := http.NewRequest("POST", endpoint, bytes.NewBuffer(requestBody))
 	if err != nil {
-		fmt.Println("Error creating request:", err)
-		return
+		return nil, fmt.Errorf("error creating request: %v", err)
 	}
 
 	req.Header.Set("Content-Type", "application/json")
@@ -40,56 +39,26 @@ func compareEndpoint(id1, id2 string, wkspId string) {
 	client := &http.Client{}
 	resp, err := client.Do(req)
 	if err != nil {
-		fmt.Println("Error sending request:", err)
-		return
+		return nil, fmt.Errorf("error sending request: %v", err)
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		fmt.Printf("Unexpected status code: %d\n", resp.StatusCode)
-		body, _ := io.ReadAll(resp.Body)
-		fmt.Printf("Response: %s\n", string(body))
-		return
-	}
-
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		fmt.Println("Error reading response:", err)
-		return
+		return nil, fmt.Errorf("error reading response: %v", err)
 	}
 
-	var diffItems []DiffItem
-	err = json.Unmarshal(body, &diffItems)
-	if err != nil {
-		fmt.Println("Error parsing JSON:", err)
-		fmt.Printf("Response: %s\n", string(body))
-		return
+	if resp.StatusCode == http.StatusUnauthorized {
+		return nil, fmt.Errorf("wrong API key")
 	}
-
-	addedCount := 0
-	removedCount := 0
-
-	fmt.Println("Summary of changes:")
-	for _, item := range diffItems {
-		if item.Added {
-			addedCount++
-			if addedCount <= 20 { // Print the first 20 additions
-				fmt.Printf("+ %s\n", item.Value)
-			}
-		} else if item.Removed {
-			removedCount++
-			if removedCount <= 20 { // Print the first 20 removals
-				fmt.Printf("- %s\n", item.Value)
-			}
-		}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code %d: %s", resp.StatusCode, string(body))
 	}
 
-	fmt.Printf("\nTotal additions: %d\n", addedCount)
-	fmt.Printf("Total removals: %d\n", removedCount)
-	if addedCount > 5 {
-		fmt.Printf("(Only the first 20 additions are shown)\n")
-	}
-	if removedCount > 5 {
-		fmt.Printf("(Only the first 20 removals are shown)\n")
+	var diffItems []DiffItem
+	err = json.Unmarshal(body, &diffItems)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing JSON: %v", err)
 	}
+	return diffItems, nil
 }
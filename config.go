@@ -1,6 +1,26 @@
 package main
 
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
 const (
 	apiBaseURL = "https://api.jsmon.sh/api/v2"
 	credFile   = "~/.jsmon/credentials"
 )
+
+// configPath returns the path of name inside the ~/.jsmon config directory,
+// creating the directory if needed.
+func configPath(name string) (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("error finding home directory: %v", err)
+	}
+	dir := filepath.Join(homeDir, ".jsmon")
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", fmt.Errorf("error creating %s: %v", dir, err)
+	}
+	return filepath.Join(dir, name), nil
+}
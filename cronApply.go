@@ -0,0 +1,269 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// cronFile is the desired monitoring configuration read by "jsmon cron apply".
+//
+//	notificationChannel: slack
+//	vulnerabilitiesType: [apiPaths, emails]
+//	time: 86400
+//	domains:
+//	  - example.com:notify
+//	  - domain: example.org
+//	    notify: false
+type cronFile struct {
+	NotificationChannel string           `json:"notificationChannel"`
+	VulnerabilitiesType []string         `json:"vulnerabilitiesType"`
+	Time                int64            `json:"time"`
+	Domains             []cronFileDomain `json:"domains"`
+}
+
+// cronFileDomain accepts either a "domain[:notify]" spec or a
+// {domain, notify} object and stores it as a spec.
+type cronFileDomain string
+
+func (d *cronFileDomain) UnmarshalJSON(data []byte) error {
+	var spec string
+	if err := json.Unmarshal(data, &spec); err == nil {
+		*d = cronFileDomain(spec)
+		return nil
+	}
+	var domain CronDomain
+	if err := json.Unmarshal(data, &domain); err != nil {
+		return fmt.Errorf("domain must be a string or an object with domain and notify: %v", err)
+	}
+	*d = cronFileDomain(fmt.Sprintf("%s:%t", domain.Domain, domain.Notify))
+	return nil
+}
+
+const cronStateFile = "cron.json"
+
+// cronState is the monitoring configuration last sent from this machine, kept
+// in ~/.jsmon/cron.json. The API has no endpoint that returns the current cron
+// configuration, so apply plans its changes against this record.
+type cronState struct {
+	Started bool `json:"started"`
+	CronConfig
+}
+
+func loadCronState() (cronState, error) {
+	var state cronState
+	path, err := configPath(cronStateFile)
+	if err != nil {
+		return state, err
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return state, nil
+	}
+	if err != nil {
+		return state, fmt.Errorf("error reading %s: %v", path, err)
+	}
+	if err := json.Unmarshal(data, &state); err != nil {
+		return state, fmt.Errorf("error parsing %s: %v", path, err)
+	}
+	return state, nil
+}
+
+func saveCronState(state cronState) error {
+	path, err := configPath(cronStateFile)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error formatting JSON: %v", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0600); err != nil {
+		return fmt.Errorf("error writing %s: %v", path, err)
+	}
+	return nil
+}
+
+// recordCronUpdate merges the fields set in config into the recorded state,
+// the same way updateCron only changes what is set.
+func recordCronUpdate(config CronConfig) error {
+	state, err := loadCronState()
+	if err != nil {
+		return err
+	}
+	if config.NotificationChannel != "" {
+		state.NotificationChannel = config.NotificationChannel
+	}
+	if len(config.VulnerabilitiesType) > 0 {
+		state.VulnerabilitiesType = config.VulnerabilitiesType
+	}
+	if config.Time != 0 {
+		state.Time = config.Time
+	}
+	if len(config.Domains) > 0 {
+		state.Domains = config.Domains
+	}
+	return saveCronState(state)
+}
+
+// loadCronFile reads and validates a cron configuration file.
+func loadCronFile(path string) (CronConfig, error) {
+	var file cronFile
+	if err := decodeConfigFile(path, &file); err != nil {
+		return CronConfig{}, err
+	}
+
+	specs := make([]string, 0, len(file.Domains))
+	for _, domain := range file.Domains {
+		specs = append(specs, string(domain))
+	}
+	config, err := buildCronConfig(file.NotificationChannel, file.VulnerabilitiesType, file.Time, specs)
+	if err != nil {
+		return config, fmt.Errorf("%s: %v", path, err)
+	}
+	if config.NotificationChannel == "" || len(config.VulnerabilitiesType) == 0 || config.Time == 0 || len(config.Domains) == 0 {
+		return config, fmt.Errorf("%s: notificationChannel, vulnerabilitiesType, time and domains are required", path)
+	}
+	return config, nil
+}
+
+// planCronChanges lists the differences between the recorded state and the
+// desired configuration, one line per change.
+func planCronChanges(current cronState, desired CronConfig) []string {
+	var plan []string
+	if !current.Started {
+		plan = append(plan, "+ start monitoring")
+	}
+	if current.NotificationChannel != desired.NotificationChannel {
+		plan = append(plan, fmt.Sprintf("~ notification channel: %q -> %q", current.NotificationChannel, desired.NotificationChannel))
+	}
+
+	currentTypes := stringSet(current.VulnerabilitiesType)
+	desiredTypes := stringSet(desired.VulnerabilitiesType)
+	for _, t := range sortedKeys(desiredTypes) {
+		if !currentTypes[t] {
+			plan = append(plan, fmt.Sprintf("+ vulnerability type %s", t))
+		}
+	}
+	for _, t := range sortedKeys(currentTypes) {
+		if !desiredTypes[t] {
+			plan = append(plan, fmt.Sprintf("- vulnerability type %s", t))
+		}
+	}
+
+	if current.Time != desired.Time {
+		plan = append(plan, fmt.Sprintf("~ time: %d -> %d", current.Time, desired.Time))
+	}
+
+	currentDomains := map[string]bool{}
+	for _, domain := range current.Domains {
+		currentDomains[domain.Domain] = domain.Notify
+	}
+	desiredDomains := map[string]bool{}
+	for _, domain := range desired.Domains {
+		desiredDomains[domain.Domain] = domain.Notify
+		notify, exists := currentDomains[domain.Domain]
+		switch {
+		case !exists:
+			plan = append(plan, fmt.Sprintf("+ domain %s (notify: %t)", domain.Domain, domain.Notify))
+		case notify != domain.Notify:
+			plan = append(plan, fmt.Sprintf("~ domain %s notify: %t -> %t", domain.Domain, notify, domain.Notify))
+		}
+	}
+	for _, domain := range current.Domains {
+		if _, exists := desiredDomains[domain.Domain]; !exists {
+			plan = append(plan, fmt.Sprintf("- domain %s", domain.Domain))
+		}
+	}
+	return plan
+}
+
+func stringSet(values []string) map[string]bool {
+	set := map[string]bool{}
+	for _, v := range values {
+		set[v] = true
+	}
+	return set
+}
+
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for k := range set {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// confirm asks a yes/no question on stdin and defaults to no.
+func confirm(question string) bool {
+	fmt.Printf("%s [y/N]: ", question)
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
+func runCronApply(args []string) error {
+	fs := newCommandFlagSet("cron apply", "cron apply -f monitoring.yaml [-dry-run] [-yes]")
+	file := fs.String("f", "", "Cron configuration file (.yaml, .yml or .json)")
+	dryRun := fs.Bool("dry-run", false, "Show the plan without applying it")
+	yes := fs.Bool("yes", false, "Apply without asking for confirmation")
+	positional, err := parseCommandArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if *file == "" && len(positional) == 1 {
+		*file = positional[0]
+	} else if len(positional) > 0 {
+		return fmt.Errorf("unexpected arguments: %s", strings.Join(positional, " "))
+	}
+	if *file == "" {
+		return fmt.Errorf("no configuration file specified, use -f monitoring.yaml")
+	}
+
+	desired, err := loadCronFile(*file)
+	if err != nil {
+		return err
+	}
+	current, err := loadCronState()
+	if err != nil {
+		return err
+	}
+
+	path, err := configPath(cronStateFile)
+	if err != nil {
+		return err
+	}
+	plan := planCronChanges(current, desired)
+	if len(plan) == 0 {
+		fmt.Printf("[INF] No changes against the local record in %s.\n", path)
+		return nil
+	}
+	if !current.Started && len(current.Domains) == 0 {
+		fmt.Fprintf(os.Stderr, "[WRN] No cron configuration is recorded in %s; if monitoring was set up elsewhere, start may fail or replace it\n", path)
+	}
+	fmt.Printf("Planned changes (vs. local record %s, not read from the server):\n", path)
+	for _, change := range plan {
+		fmt.Println("  " + change)
+	}
+	if *dryRun {
+		return nil
+	}
+	if !*yes && !confirm("Apply these changes?") {
+		fmt.Println("[INF] Aborted, nothing was changed.")
+		return nil
+	}
+
+	if !current.Started {
+		err = startCron(desired)
+	} else {
+		err = updateCron(desired)
+	}
+	if err != nil {
+		return err
+	}
+	return saveCronState(cronState{Started: true, CronConfig: desired})
+}
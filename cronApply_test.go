@@ -0,0 +1,131 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLoadCronFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "monitoring.yaml")
+	data := `notificationChannel: slack
+vulnerabilitiesType: &types [apiPaths, emails]
+time: 86400
+domains:
+  - example.com:notify
+  - domain: Example.org
+    notify: false
+`
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	got, err := loadCronFile(path)
+	if err != nil {
+		t.Fatalf("loadCronFile: %v", err)
+	}
+	want := CronConfig{
+		NotificationChannel: "slack",
+		VulnerabilitiesType: []string{"apiPaths", "emails"},
+		Time:                86400,
+		Domains: []CronDomain{
+			{Domain: "example.com", Notify: true},
+			{Domain: "example.org", Notify: false},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+
+	if err := os.WriteFile(path, []byte("notificationChannel: slack\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadCronFile(path); err == nil || !strings.Contains(err.Error(), "required") {
+		t.Errorf("got error %v for an incomplete file, want a required fields error", err)
+	}
+}
+
+func TestPlanCronChanges(t *testing.T) {
+	desired := CronConfig{
+		NotificationChannel: "slack",
+		VulnerabilitiesType: []string{"apiPaths", "emails"},
+		Time:                86400000,
+		Domains:             []CronDomain{{Domain: "a.com", Notify: true}, {Domain: "b.com"}},
+	}
+	tests := []struct {
+		name    string
+		current cronState
+		want    []string
+	}{
+		{
+			name:    "up to date",
+			current: cronState{Started: true, CronConfig: desired},
+			want:    nil,
+		},
+		{
+			name:    "never applied",
+			current: cronState{},
+			want: []string{
+				"+ start monitoring",
+				`~ notification channel: "" -> "slack"`,
+				"+ vulnerability type apiPaths",
+				"+ vulnerability type emails",
+				"~ time: 0 -> 86400000",
+				"+ domain a.com (notify: true)",
+				"+ domain b.com (notify: false)",
+			},
+		},
+		{
+			name: "changed",
+			current: cronState{Started: true, CronConfig: CronConfig{
+				NotificationChannel: "slack",
+				VulnerabilitiesType: []string{"emails", "s3Domains"},
+				Time:                86400000,
+				Domains:             []CronDomain{{Domain: "a.com"}, {Domain: "c.com"}},
+			}},
+			want: []string{
+				"+ vulnerability type apiPaths",
+				"- vulnerability type s3Domains",
+				"~ domain a.com notify: false -> true",
+				"+ domain b.com (notify: false)",
+				"- domain c.com",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := planCronChanges(tt.current, desired)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCronState(t *testing.T) {
+	home := os.Getenv("HOME")
+	defer os.Setenv("HOME", home)
+	os.Setenv("HOME", t.TempDir())
+
+	state, err := loadCronState()
+	if err != nil || state.Started {
+		t.Fatalf("got %+v, %v for a missing state file, want an empty state", state, err)
+	}
+	config := CronConfig{NotificationChannel: "slack", VulnerabilitiesType: []string{"emails"}, Time: 1, Domains: []CronDomain{{Domain: "a.com"}}}
+	if err := saveCronState(cronState{Started: true, CronConfig: config}); err != nil {
+		t.Fatal(err)
+	}
+	if err := recordCronUpdate(CronConfig{NotificationChannel: "email"}); err != nil {
+		t.Fatal(err)
+	}
+	state, err = loadCronState()
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.NotificationChannel = "email"
+	if want := (cronState{Started: true, CronConfig: config}); !reflect.DeepEqual(state, want) {
+		t.Errorf("got %+v, want %+v", state, want)
+	}
+}
@@ -0,0 +1,264 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+var cronNotificationChannels = []string{"email", "slack", "discord", "telegram"}
+
+// CronDomain is a monitored domain and whether changes on it send notifications.
+type CronDomain struct {
+	Domain string `json:"domain"`
+	Notify bool   `json:"notify"`
+}
+
+// CronConfig is a validated monitoring configuration. Zero-valued fields are
+// left out of updates so that only what was set changes.
+type CronConfig struct {
+	NotificationChannel string       `json:"notificationChannel,omitempty"`
+	VulnerabilitiesType []string     `json:"vulnerabilitiesType,omitempty"`
+	Time                int64        `json:"time,omitempty"`
+	Domains             []CronDomain `json:"domains,omitempty"`
+}
+
+// domainLists returns the domains and their notify flags as the two parallel
+// comma-separated lists StartCron and UpdateCron take.
+func (c CronConfig) domainLists() (string, string) {
+	domains := make([]string, 0, len(c.Domains))
+	notify := make([]string, 0, len(c.Domains))
+	for _, domain := range c.Domains {
+		domains = append(domains, domain.Domain)
+		notify = append(notify, strconv.FormatBool(domain.Notify))
+	}
+	return strings.Join(domains, ","), strings.Join(notify, ",")
+}
+
+// startCron and updateCron send a validated configuration through cronlib.go.
+func startCron(config CronConfig) error {
+	domains, notify := config.domainLists()
+	if err := StartCron(config.NotificationChannel, config.Time, strings.Join(config.VulnerabilitiesType, ","), domains, notify); err != nil {
+		return fmt.Errorf("error starting cron: %v", err)
+	}
+	return nil
+}
+
+func updateCron(config CronConfig) error {
+	domains, notify := config.domainLists()
+	if err := UpdateCron(config.NotificationChannel, strings.Join(config.VulnerabilitiesType, ","), domains, notify, config.Time); err != nil {
+		return fmt.Errorf("error updating cron: %v", err)
+	}
+	return nil
+}
+
+// cronDomainList collects repeated -domain flags; each value may also be a
+// comma-separated list of domain specs.
+type cronDomainList []string
+
+func (c *cronDomainList) String() string {
+	return strings.Join(*c, ",")
+}
+
+func (c *cronDomainList) Set(value string) error {
+	for _, spec := range strings.Split(value, ",") {
+		if spec = strings.TrimSpace(spec); spec != "" {
+			*c = append(*c, spec)
+		}
+	}
+	return nil
+}
+
+func runCronCommand(args []string) error {
+	usage := "cron start|stop|update|status|apply [flags]"
+	if len(args) == 0 {
+		return fmt.Errorf("usage: jsmon %s", usage)
+	}
+
+	action := args[0]
+	if action == "apply" {
+		return runCronApply(args[1:])
+	}
+	fs := newCommandFlagSet("cron "+action, usage)
+	notification := fs.String("notify", "", "Notification channel ("+strings.Join(cronNotificationChannels, ", ")+")")
+	types := fs.String("types", "", "Comma-separated vulnerability types, sent to the cron API as given (not validated)")
+	cronTime := fs.Int64("time", 0, "Value of the cron API's time field, sent as given")
+	var domains cronDomainList
+	fs.Var(&domains, "domain", "Domain to monitor as domain[:notify] (can be used multiple times)")
+
+	positional, err := parseCommandArgs(fs, args[1:])
+	if err != nil {
+		return err
+	}
+	if len(positional) > 0 {
+		return fmt.Errorf("unexpected arguments: %s", strings.Join(positional, " "))
+	}
+
+	switch action {
+	case "start":
+		config, err := buildCronConfig(*notification, splitWords(*types), *cronTime, domains)
+		if err != nil {
+			return err
+		}
+		if config.NotificationChannel == "" || len(config.VulnerabilitiesType) == 0 || config.Time == 0 || len(config.Domains) == 0 {
+			return fmt.Errorf("cron start requires -notify, -types, -time and at least one -domain")
+		}
+		if err := startCron(config); err != nil {
+			return err
+		}
+		return saveCronState(cronState{Started: true, CronConfig: config})
+	case "update":
+		config, err := buildCronConfig(*notification, splitWords(*types), *cronTime, domains)
+		if err != nil {
+			return err
+		}
+		if config.NotificationChannel == "" && len(config.VulnerabilitiesType) == 0 && config.Time == 0 && len(config.Domains) == 0 {
+			return fmt.Errorf("nothing to update: set at least one of -notify, -types, -time or -domain")
+		}
+		if err := updateCron(config); err != nil {
+			return err
+		}
+		return recordCronUpdate(config)
+	case "stop":
+		if err := StopCron(); err != nil {
+			return fmt.Errorf("error stopping cron: %v", err)
+		}
+		state, err := loadCronState()
+		if err != nil {
+			return err
+		}
+		state.Started = false
+		return saveCronState(state)
+	case "status":
+		state, err := loadCronState()
+		if err != nil {
+			return err
+		}
+		writeCronStatus(os.Stdout, state)
+		return nil
+	case "-h", "-help", "--help":
+		fs.Usage()
+		return flag.ErrHelp
+	default:
+		return fmt.Errorf("unknown cron action %q, use: jsmon %s", action, usage)
+	}
+}
+
+// buildCronConfig validates the cron settings. Empty settings produce zero
+// values. Checking the vulnerability types and time against the values the
+// server accepts is out of scope: the API does not document them, so they are
+// passed through unchanged and only empty, duplicate and negative ones are
+// rejected.
+func buildCronConfig(notification string, types []string, cronTime int64, domains []string) (CronConfig, error) {
+	var config CronConfig
+	var err error
+
+	if notification != "" {
+		if config.NotificationChannel, err = parseNotificationChannel(notification); err != nil {
+			return config, err
+		}
+	}
+	if config.VulnerabilitiesType, err = parseVulnerabilityTypes(types); err != nil {
+		return config, err
+	}
+	if cronTime < 0 {
+		return config, fmt.Errorf("invalid time %d, it must be positive", cronTime)
+	}
+	config.Time = cronTime
+	if len(domains) > 0 {
+		if config.Domains, err = parseCronDomains(domains); err != nil {
+			return config, err
+		}
+	}
+	return config, nil
+}
+
+// writeCronStatus prints the cron configuration recorded on this machine. The
+// API has no endpoint that returns the server's configuration, so changes made
+// elsewhere do not show up.
+func writeCronStatus(w io.Writer, state cronState) {
+	if !state.Started && state.NotificationChannel == "" && len(state.VulnerabilitiesType) == 0 && state.Time == 0 && len(state.Domains) == 0 {
+		fmt.Fprintln(w, "[INF] No cron configuration recorded on this machine")
+		return
+	}
+	fmt.Fprintln(w, "[INF] Cron configuration last sent from this machine (local record, not read from the server)")
+	status := "stopped"
+	if state.Started {
+		status = "started"
+	}
+	fmt.Fprintf(w, "Status: %s\n", status)
+	fmt.Fprintf(w, "Notification channel: %s\n", state.NotificationChannel)
+	fmt.Fprintf(w, "Vulnerability types: %s\n", strings.Join(state.VulnerabilitiesType, ", "))
+	fmt.Fprintf(w, "Time: %d\n", state.Time)
+	fmt.Fprintf(w, "Domains (%d):\n", len(state.Domains))
+	for _, domain := range state.Domains {
+		if domain.Notify {
+			fmt.Fprintf(w, "  %s (notify)\n", domain.Domain)
+		} else {
+			fmt.Fprintf(w, "  %s\n", domain.Domain)
+		}
+	}
+}
+
+func parseNotificationChannel(channel string) (string, error) {
+	channel = strings.ToLower(strings.TrimSpace(channel))
+	for _, valid := range cronNotificationChannels {
+		if channel == valid {
+			return channel, nil
+		}
+	}
+	return "", fmt.Errorf("invalid notification channel %q, valid channels: %s", channel, strings.Join(cronNotificationChannels, ", "))
+}
+
+// parseVulnerabilityTypes trims the types and rejects empty or repeated ones.
+func parseVulnerabilityTypes(types []string) ([]string, error) {
+	var result []string
+	seen := map[string]bool{}
+	for _, t := range types {
+		t = strings.TrimSpace(t)
+		if t == "" || strings.ContainsAny(t, ", ") {
+			return nil, fmt.Errorf("invalid vulnerability type %q", t)
+		}
+		if seen[t] {
+			return nil, fmt.Errorf("vulnerability type %s is listed more than once", t)
+		}
+		seen[t] = true
+		result = append(result, t)
+	}
+	return result, nil
+}
+
+// parseCronDomains parses domain specs of the form "example.com" or
+// "example.com:notify". A spec may also end in ":true" or ":false".
+func parseCronDomains(specs []string) ([]CronDomain, error) {
+	var domains []CronDomain
+	seen := map[string]bool{}
+	for _, spec := range specs {
+		domain := spec
+		notify := false
+		if i := strings.LastIndex(spec, ":"); i >= 0 {
+			domain = spec[:i]
+			switch strings.ToLower(spec[i+1:]) {
+			case "notify", "true":
+				notify = true
+			case "nonotify", "false":
+				notify = false
+			default:
+				return nil, fmt.Errorf("invalid domain spec %q, use domain or domain:notify", spec)
+			}
+		}
+		domain = normalizeHost(domain)
+		if !strings.Contains(domain, ".") {
+			return nil, fmt.Errorf("invalid domain %q in spec %q", domain, spec)
+		}
+		if seen[domain] {
+			return nil, fmt.Errorf("domain %s is listed more than once", domain)
+		}
+		seen[domain] = true
+		domains = append(domains, CronDomain{Domain: domain, Notify: notify})
+	}
+	return domains, nil
+}
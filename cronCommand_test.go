@@ -0,0 +1,118 @@
+package main
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestBuildCronConfig(t *testing.T) {
+	config, err := buildCronConfig(" Slack ", []string{"apiPaths", " emails"}, 3600, []string{"https://Example.com/:notify", "example.org:false", "example.net"})
+	if err != nil {
+		t.Fatalf("buildCronConfig: %v", err)
+	}
+	want := CronConfig{
+		NotificationChannel: "slack",
+		VulnerabilitiesType: []string{"apiPaths", "emails"},
+		Time:                3600,
+		Domains: []CronDomain{
+			{Domain: "example.com", Notify: true},
+			{Domain: "example.org"},
+			{Domain: "example.net"},
+		},
+	}
+	if !reflect.DeepEqual(config, want) {
+		t.Errorf("got %+v, want %+v", config, want)
+	}
+	domains, notify := config.domainLists()
+	if domains != "example.com,example.org,example.net" || notify != "true,false,false" {
+		t.Errorf("got domain lists %q and %q", domains, notify)
+	}
+}
+
+func TestBuildCronConfigErrors(t *testing.T) {
+	tests := []struct {
+		name         string
+		notification string
+		types        []string
+		time         int64
+		domains      []string
+	}{
+		{"unknown channel", "pager", nil, 0, nil},
+		{"empty type", "", []string{"emails", ""}, 0, nil},
+		{"repeated type", "", []string{"emails", "emails"}, 0, nil},
+		{"negative time", "", nil, -1, nil},
+		{"bad notify flag", "", nil, 0, []string{"example.com:maybe"}},
+		{"not a domain", "", nil, 0, []string{"localhost"}},
+		{"repeated domain", "", nil, 0, []string{"example.com", "EXAMPLE.com:notify"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := buildCronConfig(tt.notification, tt.types, tt.time, tt.domains); err == nil {
+				t.Error("got no error")
+			}
+		})
+	}
+}
+
+func TestWriteCronStatus(t *testing.T) {
+	var buf bytes.Buffer
+	writeCronStatus(&buf, cronState{})
+	if !strings.Contains(buf.String(), "No cron configuration recorded") {
+		t.Errorf("got %q for an empty record", buf.String())
+	}
+
+	buf.Reset()
+	writeCronStatus(&buf, cronState{Started: true, CronConfig: CronConfig{
+		NotificationChannel: "slack",
+		VulnerabilitiesType: []string{"apiPaths", "emails"},
+		Time:                86400,
+		Domains:             []CronDomain{{Domain: "example.com", Notify: true}, {Domain: "example.org"}},
+	}})
+	want := `[INF] Cron configuration last sent from this machine (local record, not read from the server)
+Status: started
+Notification channel: slack
+Vulnerability types: apiPaths, emails
+Time: 86400
+Domains (2):
+  example.com (notify)
+  example.org
+`
+	if buf.String() != want {
+		t.Errorf("got\n%s\nwant\n%s", buf.String(), want)
+	}
+}
+
+func TestReadCronResponse(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		wantErr string
+	}{
+		{"ok", 200, `{"message":"Cron started"}`, ""},
+		{"unauthorized", 401, `{"message":"Invalid key"}`, "wrong API key"},
+		{"server message", 400, `{"message":"Cron already running"}`, "Cron already running (status code 400)"},
+		{"no message", 502, `<html>bad gateway</html>`, "status code 502"},
+		{"ok without json", 200, `done`, "failed to unmarshal JSON response"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			rec.WriteHeader(tt.status)
+			rec.WriteString(tt.body)
+			err := readCronResponse(rec.Result())
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("got error %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("got error %v, want one containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -14,7 +14,7 @@ type Response struct {
 	Data    string `json:"data"`
 }
 
-func StartCron(cronNotification string, cronTime int64, cronType string, cronDomain string, cronDomainNotify string) {
+func StartCron(cronNotification string, cronTime int64, cronType string, cronDomain string, cronDomainNotify string) error {
 
 	notification := strings.TrimSpace(cronNotification)
 	//split vulnerabilities
@@ -22,8 +22,7 @@ func StartCron(cronNotification string, cronTime int64, cronType string, cronDom
 	cronDomains := strings.Split(cronDomain, ",")
 	cronDomainsNotify := strings.Split(cronDomainNotify, ",")
 	if len(cronDomains) != len(cronDomainsNotify) {
-		fmt.Println("Invalid format for cronDomains and cronDomainsNotify. Use: domain1,domain2,domain3 domainNotify1,domainNotify2,domainNotify3")
-		return
+		return fmt.Errorf("invalid format for cronDomains and cronDomainsNotify. Use: domain1,domain2,domain3 domainNotify1,domainNotify2,domainNotify3")
 	}
 
 	//trim domains and domainsNotify
@@ -50,8 +49,7 @@ func StartCron(cronNotification string, cronTime int64, cronType string, cronDom
 	var url = baseUrl + "/startCron"
 	req, err := http.NewRequest(method, url, nil)
 	if err != nil {
-		fmt.Printf("failed to create request: %v", err)
-		return
+		return fmt.Errorf("failed to create request: %v", err)
 	}
 	req.Header.Set("X-Jsmon-Key", apiKey)
 	req.Header.Set("Content-Type", "application/json")
@@ -64,36 +62,21 @@ func StartCron(cronNotification string, cronTime int64, cronType string, cronDom
 	}
 	jsonData, err := json.Marshal(data)
 	if err != nil {
-		fmt.Printf("failed to marshal JSON: %v", err)
-		return
+		return fmt.Errorf("failed to marshal JSON: %v", err)
 	}
 
 	req.Body = io.NopCloser(bytes.NewReader(jsonData))
 
 	resp, err := client.Do(req)
 	if err != nil {
-		fmt.Printf("failed to send request: %v", err)
-		return
+		return fmt.Errorf("failed to send request: %v", err)
 	}
 	defer resp.Body.Close()
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		fmt.Printf("failed to read response body: %v", err)
-		return
-	}
-	var response Response
-	err = json.Unmarshal(body, &response)
-	if err != nil {
-		fmt.Printf("failed to unmarshal JSON response: %v", err)
-		return
-	}
-
-	fmt.Println("Message:", response.Message)
-
+	return readCronResponse(resp)
 }
 
-func StopCron() {
+func StopCron() error {
 	apiKey := strings.TrimSpace(getAPIKey())
 	baseUrl := apiBaseURL
 	client := &http.Client{}
@@ -101,37 +84,21 @@ func StopCron() {
 	var url = baseUrl + "/stopCron"
 	req, err := http.NewRequest(method, url, nil)
 	if err != nil {
-		fmt.Printf("failed to create request: %v", err)
-		return
+		return fmt.Errorf("failed to create request: %v", err)
 	}
 	req.Header.Set("X-Jsmon-Key", apiKey)
 	req.Header.Set("Content-Type", "application/json")
 
 	resp, err := client.Do(req)
 	if err != nil {
-		fmt.Printf("failed to send request: %v", err)
-		return
+		return fmt.Errorf("failed to send request: %v", err)
 	}
 	defer resp.Body.Close()
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		fmt.Printf("failed to read response body: %v", err)
-		return
-	}
-
-	var response Response
-	err = json.Unmarshal(body, &response)
-	if err != nil {
-		fmt.Printf("failed to unmarshal JSON response: %v", err)
-		return
-	}
-
-	fmt.Println("Message:", response.Message)
-
+	return readCronResponse(resp)
 }
 
-func UpdateCron(cronNotification string, cronType string, cronDomain string, cronDomainNotify string, cronTime int64) {
+func UpdateCron(cronNotification string, cronType string, cronDomain string, cronDomainNotify string, cronTime int64) error {
 	apiKey := strings.TrimSpace(getAPIKey())
 	baseUrl := apiBaseURL
 
@@ -141,8 +108,7 @@ func UpdateCron(cronNotification string, cronType string, cronDomain string, cro
 
 	req, err := http.NewRequest(method, url, nil)
 	if err != nil {
-		fmt.Printf("failed to create request: %v", err)
-		return
+		return fmt.Errorf("failed to create request: %v", err)
 	}
 
 	req.Header.Set("X-Jsmon-Key", apiKey)
@@ -163,8 +129,7 @@ func UpdateCron(cronNotification string, cronType string, cronDomain string, cro
 		cronDomains := strings.Split(cronDomain, ",")
 		cronDomainsNotify := strings.Split(cronDomainNotify, ",")
 		if len(cronDomains) != len(cronDomainsNotify) {
-			fmt.Println("Invalid format for cronDomains and cronDomainsNotify. Use: domain1,domain2,domain3 domainNotify1,domainNotify2,domainNotify3")
-			return
+			return fmt.Errorf("invalid format for cronDomains and cronDomainsNotify. Use: domain1,domain2,domain3 domainNotify1,domainNotify2,domainNotify3")
 		}
 		//trim domains and domainsNotify
 		for i := 0; i < len(cronDomains); i++ {
@@ -186,31 +151,41 @@ func UpdateCron(cronNotification string, cronType string, cronDomain string, cro
 
 	jsonData, err := json.Marshal(data)
 	if err != nil {
-		fmt.Printf("failed to marshal JSON: %v", err)
-		return
+		return fmt.Errorf("failed to marshal JSON: %v", err)
 	}
 
 	req.Body = io.NopCloser(bytes.NewReader(jsonData))
 
 	resp, err := client.Do(req)
 	if err != nil {
-		fmt.Printf("failed to send request: %v", err)
-		return
+		return fmt.Errorf("failed to send request: %v", err)
 	}
 	defer resp.Body.Close()
 
+	return readCronResponse(resp)
+}
+
+// readCronResponse prints the message of a successful cron call and turns any
+// other status into an error.
+func readCronResponse(resp *http.Response) error {
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		fmt.Printf("failed to read response body: %v", err)
-		return
+		return fmt.Errorf("failed to read response body: %v", err)
 	}
 	var response Response
-	err = json.Unmarshal(body, &response)
-	if err != nil {
-		fmt.Printf("failed to unmarshal JSON response: %v", err)
-		return
+	jsonErr := json.Unmarshal(body, &response)
+	if resp.StatusCode == http.StatusUnauthorized {
+		return fmt.Errorf("wrong API key")
+	}
+	if resp.StatusCode != http.StatusOK {
+		if jsonErr == nil && response.Message != "" {
+			return fmt.Errorf("%s (status code %d)", response.Message, resp.StatusCode)
+		}
+		return fmt.Errorf("status code %d", resp.StatusCode)
+	}
+	if jsonErr != nil {
+		return fmt.Errorf("failed to unmarshal JSON response: %v", jsonErr)
 	}
-
 	fmt.Println("Message:", response.Message)
-
+	return nil
 }
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+// diffOutputOptions controls how a line diff is rendered.
+type diffOutputOptions struct {
+	Context int
+	Stat    bool
+	NoColor bool
+	Output  string
+}
+
+func (o *diffOutputOptions) register(fs *flag.FlagSet) {
+	fs.IntVar(&o.Context, "context", 3, "Number of unchanged lines shown around each change")
+	fs.BoolVar(&o.Stat, "stat", false, "Show a summary of the changes instead of the diff")
+	fs.BoolVar(&o.NoColor, "no-color", false, "Disable colorized output")
+	fs.StringVar(&o.Output, "o", "", "Write the diff to a file (e.g. changes.patch) instead of stdout")
+}
+
+// renderDiff writes the diff between two stored versions to stdout or to
+// options.Output. Files never contain color codes, and no file is written
+// when there are no differences.
+func renderDiff(items []DiffItem, oldName, newName string, options diffOutputOptions) error {
+	lines := diffLinesFromItems(items)
+	hunks := buildHunks(lines, options.Context)
+	if len(hunks) == 0 {
+		fmt.Println("[INF] No differences found.")
+		return nil
+	}
+
+	var w io.Writer = os.Stdout
+	var buf bytes.Buffer
+	colorize := !options.NoColor && !color.NoColor
+	if options.Output != "" {
+		w = &buf
+		colorize = false
+	}
+
+	if options.Stat {
+		writeDiffStat(w, oldName+" => "+newName, lines, hunks, colorize)
+	} else {
+		writeUnifiedDiff(w, oldName, newName, hunks, colorize)
+	}
+
+	if options.Output != "" {
+		if err := os.WriteFile(options.Output, buf.Bytes(), 0644); err != nil {
+			return fmt.Errorf("error writing %s: %v", options.Output, err)
+		}
+		fmt.Printf("[INF] Diff written to %s\n", options.Output)
+	}
+	return nil
+}
+
+func runDiffCommand(args []string) error {
+	fs := newCommandFlagSet("diff", "diff <jsmonId1> <jsmonId2> [flags] | diff -semantic <old> <new> [flags]")
+	wkspId := fs.String("wksp", *workspaceFlag, "Workspace ID")
+	semantic := fs.Bool("semantic", false, "Compare two jsmon IDs, local files or URLs by their strings, URLs, API paths and secrets, ignoring minifier noise")
+	jsonOutput := fs.Bool("json", false, "Print the semantic diff as JSON")
+	var headerFlags stringSliceFlag
+	fs.Var(&headerFlags, "H", "Header for fetching -semantic URLs in the format 'Key: Value' (can be used multiple times)")
+	var options diffOutputOptions
+	options.register(fs)
+	positional, err := parseCommandArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 2 {
+		fs.Usage()
+		return fmt.Errorf("diff needs exactly two arguments, got %d", len(positional))
+	}
+	if *semantic {
+		headerList := append(append([]string{}, headers...), headerFlags...)
+		return runSemanticDiff(strings.TrimSpace(positional[0]), strings.TrimSpace(positional[1]), *wkspId, headerList, *jsonOutput, options.Output)
+	}
+	if err := requireWorkspace(*wkspId); err != nil {
+		return err
+	}
+
+	id1, id2 := strings.TrimSpace(positional[0]), strings.TrimSpace(positional[1])
+	items, err := compareEndpoint(id1, id2, *wkspId)
+	if err != nil {
+		return err
+	}
+	return renderDiff(items, id1, id2, options)
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRenderDiffOutput(t *testing.T) {
+	dir := t.TempDir()
+	options := diffOutputOptions{Context: 3, Output: filepath.Join(dir, "same.patch")}
+	same := []DiffItem{{Value: "a\nb\n"}}
+	if err := renderDiff(same, "old.js", "new.js", options); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(options.Output); !os.IsNotExist(err) {
+		t.Errorf("got %v for an empty diff, want no file", err)
+	}
+
+	options.Output = filepath.Join(dir, "changed.patch")
+	changed := []DiffItem{{Value: "a\n"}, {Removed: true, Value: "b\n"}, {Added: true, Value: "c\n"}}
+	if err := renderDiff(changed, "old.js", "new.js", options); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(options.Output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "-b\n+c\n") || strings.Contains(string(data), "\x1b[") {
+		t.Errorf("got diff file %q", data)
+	}
+}
+
+// parseDiffLines turns " a", "-b" and "+c" strings into diff lines.
+func parseDiffLines(specs ...string) []diffLine {
+	lines := make([]diffLine, len(specs))
+	for i, spec := range specs {
+		lines[i] = diffLine{Op: spec[0], Text: spec[1:]}
+	}
+	return lines
+}
+
+func TestBuildHunks(t *testing.T) {
+	tests := []struct {
+		name    string
+		lines   []diffLine
+		context int
+		want    string
+	}{
+		{"one change with context", parseDiffLines(" a", " b", "-c", "+C", " d", " e"), 1,
+			"@@ -2,3 +2,3 @@\n b\n-c\n+C\n d\n"},
+		{"context clipped at the edges", parseDiffLines("-a", " b", " c", " d", "+e"), 5,
+			"@@ -1,4 +1,4 @@\n-a\n b\n c\n d\n+e\n"},
+		{"close changes merge", parseDiffLines(" a", "-b", " c", " d", "+e", " f"), 1,
+			"@@ -1,5 +1,5 @@\n a\n-b\n c\n d\n+e\n f\n"},
+		{"distant changes split", parseDiffLines(" a", "-b", " c", " d", " e", "+f", " g"), 1,
+			"@@ -1,3 +1,2 @@\n a\n-b\n c\n@@ -5,2 +4,3 @@\n e\n+f\n g\n"},
+		{"addition to an empty file", parseDiffLines("+a", "+b"), 3,
+			"@@ -0,0 +1,2 @@\n+a\n+b\n"},
+		{"removal of a single line", parseDiffLines(" a", "-b"), 0,
+			"@@ -2 +1,0 @@\n-b\n"},
+		{"no changes", parseDiffLines(" a", " b"), 3, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf strings.Builder
+			writeUnifiedDiff(&buf, "old.js", "new.js", buildHunks(tt.lines, tt.context), false)
+			want := "--- a/old.js\n+++ b/new.js\n" + tt.want
+			if buf.String() != want {
+				t.Errorf("got\n%s\nwant\n%s", buf.String(), want)
+			}
+		})
+	}
+}
+
+func TestRenderDiffWriteError(t *testing.T) {
+	changed := []DiffItem{{Removed: true, Value: "b\n"}, {Added: true, Value: "c\n"}}
+	targets := []string{filepath.Join(t.TempDir(), "missing", "out.patch")}
+	if _, err := os.Stat("/dev/full"); err == nil {
+		targets = append(targets, "/dev/full")
+	}
+	for _, target := range targets {
+		err := renderDiff(changed, "old.js", "new.js", diffOutputOptions{Context: 3, Output: target})
+		if err == nil || !strings.Contains(err.Error(), "error writing "+target) {
+			t.Errorf("%s: got error %v", target, err)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// extractValues fetches the given backend fields for each domain and returns
+// their values deduplicated and sorted.
+func extractValues(backends, domains []string, size int, wkspId string) ([]string, error) {
+	seen := map[string]bool{}
+	for _, domain := range domains {
+		results, err := fetchIntelligenceResults("domain", domain, backends, size, wkspId)
+		if err != nil {
+			return nil, fmt.Errorf("error fetching results for %s: %v", domain, err)
+		}
+		for _, result := range results {
+			for _, backend := range backends {
+				for _, value := range result.Field(backend).Strings() {
+					if value = strings.TrimSpace(value); value != "" {
+						seen[value] = true
+					}
+				}
+			}
+		}
+	}
+	values := make([]string, 0, len(seen))
+	for value := range seen {
+		values = append(values, value)
+	}
+	sort.Strings(values)
+	return values, nil
+}
+
+func runExtractCommand(args []string) error {
+	fs := newCommandFlagSet("extract", "extract <category>[,<category>...] -d example.com [flags]")
+	domains := fs.String("d", "", "Comma-separated domains to extract from")
+	wkspId := fs.String("wksp", *workspaceFlag, "Workspace ID")
+	size := fs.Int("s", 100, "Number of JS files to fetch results for per domain")
+	positional, err := parseCommandArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		fs.Usage()
+		return fmt.Errorf("extract needs one category, run \"jsmon query fields\" to list them")
+	}
+	var backends []string
+	for _, category := range splitWords(positional[0]) {
+		backend, err := resolveQueryField(category)
+		if err != nil {
+			return fmt.Errorf("invalid category: %v", err)
+		}
+		backends = append(backends, backend)
+	}
+	if len(backends) == 0 {
+		return fmt.Errorf("extract needs one category, run \"jsmon query fields\" to list them")
+	}
+	domainList := splitWords(*domains)
+	if len(domainList) == 0 {
+		return fmt.Errorf("no domain specified, use -d example.com")
+	}
+	if err := requireWorkspace(*wkspId); err != nil {
+		return err
+	}
+
+	values, err := extractValues(backends, domainList, *size, *wkspId)
+	if err != nil {
+		return err
+	}
+	for _, value := range values {
+		fmt.Println(value)
+	}
+	return nil
+}
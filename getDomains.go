@@ -8,13 +8,12 @@ import (
 	"strings"
 )
 
-func getDomains(wkspId string) {
+func fetchDomains(wkspId string) ([]string, error) {
 	endpoint := fmt.Sprintf("%s/getDomains?wkspId=%s", apiBaseURL, wkspId)
 
 	req, err := http.NewRequest("GET", endpoint, nil)
 	if err != nil {
-		fmt.Println("Error creating request:", err)
-		return
+		return nil, fmt.Errorf("error creating request: %v", err)
 	}
 
 	req.Header.Set("Content-Type", "application/json")
@@ -23,30 +22,35 @@ func getDomains(wkspId string) {
 	client := &http.Client{}
 	resp, err := client.Do(req)
 	if err != nil {
-		fmt.Println("Error sending request:", err)
-		return
+		return nil, fmt.Errorf("error sending request: %v", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode == http.StatusUnauthorized {
-		fmt.Println("[ERR] Wrong API key")
-		return
+		return nil, fmt.Errorf("wrong API key")
 	}
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		fmt.Println("Error reading response:", err)
-		return
+		return nil, fmt.Errorf("error reading response: %v", err)
 	}
 
 	var domains []string
 	err = json.Unmarshal(body, &domains)
 	if err != nil {
-		fmt.Println("Error parsing JSON:", err)
-		return
+		return nil, fmt.Errorf("error parsing JSON: %v", err)
+	}
+	return domains, nil
+}
+
+func getDomains(wkspId string) error {
+	domains, err := fetchDomains(wkspId)
+	if err != nil {
+		return err
 	}
 
 	for _, domain := range domains {
 		fmt.Println(domain)
 	}
+	return nil
 }
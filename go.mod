@@ -4,5 +4,6 @@ go 1.16
 
 require (
 	github.com/common-nighthawk/go-figure v0.0.0-20210622060536-734e95fb86be // indirect
-	github.com/fatih/color v1.18.0 // indirect
+	github.com/fatih/color v1.18.0
+	gopkg.in/yaml.v3 v3.0.1
 )
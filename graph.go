@@ -0,0 +1,214 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// graphNode is an asset or finding: a domain, JS file, email, API path, etc.
+type graphNode struct {
+	ID    string            `json:"id"`
+	Kind  string            `json:"kind"`
+	Label string            `json:"label"`
+	Attrs map[string]string `json:"attrs,omitempty"`
+}
+
+type graphEdge struct {
+	From  string `json:"from"`
+	To    string `json:"to"`
+	Label string `json:"label"`
+}
+
+// graph is a directed graph of assets in insertion order. Nodes are keyed by
+// kind and label, so adding the same asset twice returns the same node.
+type graph struct {
+	nodes []*graphNode
+	index map[string]*graphNode
+	edges []graphEdge
+	seen  map[graphEdge]bool
+}
+
+func newGraph() *graph {
+	return &graph{index: map[string]*graphNode{}, seen: map[graphEdge]bool{}}
+}
+
+// addNode returns the node for kind and label, adding it if it is new. An
+// empty label is not an asset, so it returns nil and adds nothing.
+func (g *graph) addNode(kind, label string) *graphNode {
+	if strings.TrimSpace(label) == "" {
+		return nil
+	}
+	id := kind + ":" + label
+	if node, ok := g.index[id]; ok {
+		return node
+	}
+	node := &graphNode{ID: id, Kind: kind, Label: label}
+	g.nodes = append(g.nodes, node)
+	g.index[id] = node
+	return node
+}
+
+// addNodeLimit is addNode for a graph capped at limit nodes: it returns nil
+// instead of adding a new node once the graph is full. Like addNode it also
+// returns nil for an empty label.
+func (g *graph) addNodeLimit(kind, label string, limit int) *graphNode {
+	if strings.TrimSpace(label) == "" {
+		return nil
+	}
+	if node, ok := g.index[kind+":"+label]; ok {
+		return node
+	}
+	if len(g.nodes) >= limit {
+		return nil
+	}
+	return g.addNode(kind, label)
+}
+
+// addEdge links two nodes once; it does nothing if either node is nil.
+func (g *graph) addEdge(from, to *graphNode, label string) {
+	if from == nil || to == nil {
+		return
+	}
+	edge := graphEdge{From: from.ID, To: to.ID, Label: label}
+	if !g.seen[edge] {
+		g.seen[edge] = true
+		g.edges = append(g.edges, edge)
+	}
+}
+
+func (g *graph) writeJSON(w io.Writer) error {
+	nodes := g.nodes
+	if nodes == nil {
+		nodes = []*graphNode{}
+	}
+	edges := g.edges
+	if edges == nil {
+		edges = []graphEdge{}
+	}
+	jsonData, err := json.MarshalIndent(struct {
+		Nodes []*graphNode `json:"nodes"`
+		Edges []graphEdge  `json:"edges"`
+	}{nodes, edges}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error formatting JSON: %v", err)
+	}
+	_, err = fmt.Fprintln(w, string(jsonData))
+	return err
+}
+
+// graphKindStyles gives each node kind a Graphviz shape and fill color.
+var graphKindStyles = map[string][2]string{
+	"domain":  {"box", "#cfe2f3"},
+	"jsfile":  {"note", "#fff2cc"},
+	"api":     {"ellipse", "#d9ead3"},
+	"bucket":  {"cylinder", "#f4cccc"},
+	"secret":  {"octagon", "#ea9999"},
+	"email":   {"ellipse", "#d9d2e9"},
+	"package": {"component", "#eeeeee"},
+}
+
+func dotQuote(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	return `"` + r.Replace(s) + `"`
+}
+
+// writeDOT writes the graph in Graphviz DOT format.
+func (g *graph) writeDOT(w io.Writer) error {
+	var b bytes.Buffer
+	b.WriteString("digraph jsmon {\n  rankdir=LR;\n  node [style=filled, fillcolor=\"#ffffff\"];\n")
+	for _, node := range g.nodes {
+		attrs := "label=" + dotQuote(node.Label)
+		if style, ok := graphKindStyles[node.Kind]; ok {
+			attrs += fmt.Sprintf(", shape=%s, fillcolor=%s", style[0], dotQuote(style[1]))
+		}
+		fmt.Fprintf(&b, "  %s [%s];\n", dotQuote(node.ID), attrs)
+	}
+	for _, edge := range g.edges {
+		fmt.Fprintf(&b, "  %s -> %s [label=%s];\n", dotQuote(edge.From), dotQuote(edge.To), dotQuote(edge.Label))
+	}
+	b.WriteString("}\n")
+	_, err := w.Write(b.Bytes())
+	return err
+}
+
+func xmlEscape(s string) string {
+	var b bytes.Buffer
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+// writeGraphML writes the graph in GraphML, which Gephi and yEd can import.
+// Node attributes become extra data keys.
+func (g *graph) writeGraphML(w io.Writer) error {
+	attrNames := map[string]bool{}
+	for _, node := range g.nodes {
+		for name := range node.Attrs {
+			attrNames[name] = true
+		}
+	}
+
+	var b bytes.Buffer
+	b.WriteString(xml.Header)
+	b.WriteString(`<graphml xmlns="http://graphml.graphdrawing.org/xmlns">` + "\n")
+	b.WriteString(`  <key id="label" for="node" attr.name="label" attr.type="string"/>` + "\n")
+	b.WriteString(`  <key id="kind" for="node" attr.name="kind" attr.type="string"/>` + "\n")
+	for _, name := range sortedKeys(attrNames) {
+		fmt.Fprintf(&b, "  <key id=\"attr_%s\" for=\"node\" attr.name=\"%s\" attr.type=\"string\"/>\n", xmlEscape(name), xmlEscape(name))
+	}
+	b.WriteString(`  <key id="edgelabel" for="edge" attr.name="label" attr.type="string"/>` + "\n")
+	b.WriteString(`  <graph id="jsmon" edgedefault="directed">` + "\n")
+
+	ids := map[string]string{}
+	for i, node := range g.nodes {
+		ids[node.ID] = fmt.Sprintf("n%d", i)
+		fmt.Fprintf(&b, "    <node id=\"%s\">\n", ids[node.ID])
+		fmt.Fprintf(&b, "      <data key=\"label\">%s</data>\n", xmlEscape(node.Label))
+		fmt.Fprintf(&b, "      <data key=\"kind\">%s</data>\n", xmlEscape(node.Kind))
+		names := make([]string, 0, len(node.Attrs))
+		for name := range node.Attrs {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			fmt.Fprintf(&b, "      <data key=\"attr_%s\">%s</data>\n", xmlEscape(name), xmlEscape(node.Attrs[name]))
+		}
+		b.WriteString("    </node>\n")
+	}
+	for i, edge := range g.edges {
+		fmt.Fprintf(&b, "    <edge id=\"e%d\" source=\"%s\" target=\"%s\">\n", i, ids[edge.From], ids[edge.To])
+		fmt.Fprintf(&b, "      <data key=\"edgelabel\">%s</data>\n", xmlEscape(edge.Label))
+		b.WriteString("    </edge>\n")
+	}
+	b.WriteString("  </graph>\n</graphml>\n")
+	_, err := w.Write(b.Bytes())
+	return err
+}
+
+// checkGraphFormat returns an error unless format is json, dot or graphml, so
+// commands can reject it before doing any work.
+func checkGraphFormat(format string) error {
+	switch strings.ToLower(format) {
+	case "json", "dot", "graphml":
+		return nil
+	}
+	return fmt.Errorf("unknown graph format %q, use json, dot or graphml", format)
+}
+
+// writeGraph writes g in the named format: json, dot or graphml.
+func writeGraph(w io.Writer, g *graph, format string) error {
+	if err := checkGraphFormat(format); err != nil {
+		return err
+	}
+	switch strings.ToLower(format) {
+	case "dot":
+		return g.writeDOT(w)
+	case "graphml":
+		return g.writeGraphML(w)
+	}
+	return g.writeJSON(w)
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// graphNodeKind returns the node kind used for values of a backend field.
+func graphNodeKind(backend string) string {
+	if kind, ok := rsearchInputTypes[backend]; ok {
+		return kind
+	}
+	for name, b := range fieldMapping {
+		if b == backend {
+			return name
+		}
+	}
+	return backend
+}
+
+// buildWorkspaceGraph links each domain to the JS files found on it, each JS
+// file to the values of the given fields, and, with secrets set, each JS file
+// to the secrets the scanner detected in it.
+func buildWorkspaceGraph(domains, fields []string, secrets bool, size int, wkspId string) (*graph, error) {
+	g := newGraph()
+	jsNodes := map[string]*graphNode{}
+	for _, domain := range domains {
+		results, err := fetchIntelligenceResults("domain", domain, fields, size, wkspId)
+		if err != nil {
+			return nil, fmt.Errorf("error fetching results for %s: %v", domain, err)
+		}
+		domainNode := g.addNode("domain", domain)
+		for _, result := range results {
+			jsNode := g.addNode("jsfile", result.URL)
+			if jsNode == nil {
+				continue
+			}
+			if result.JsmonId != "" {
+				jsNode.Attrs = map[string]string{"jsmonId": result.JsmonId}
+				jsNodes[result.JsmonId] = jsNode
+			}
+			g.addEdge(domainNode, jsNode, "loads")
+			for _, field := range fields {
+				kind := graphNodeKind(field)
+				for _, value := range result.Field(field).Strings() {
+					g.addEdge(jsNode, g.addNode(kind, value), "contains")
+				}
+			}
+		}
+	}
+
+	if secrets {
+		scanner, err := fetchScannerResults(wkspId)
+		if err != nil {
+			return nil, fmt.Errorf("error fetching secrets: %v", err)
+		}
+		for _, item := range scanner.Data {
+			jsNode, ok := jsNodes[item.JsmonId]
+			if !ok {
+				// Only secrets in JS files of the selected domains are included.
+				continue
+			}
+			for _, detected := range item.DetectedWords {
+				for _, word := range detected.Words {
+					if strings.TrimSpace(word) == "" {
+						continue
+					}
+					secretNode := g.addNode("secret", detected.Name+": "+word)
+					secretNode.Attrs = map[string]string{"module": detected.Name}
+					g.addEdge(jsNode, secretNode, "exposes")
+				}
+			}
+		}
+	}
+	return g, nil
+}
+
+func runGraphCommand(args []string) error {
+	fs := newCommandFlagSet("graph", "graph -wksp <id> [-format dot|graphml|json] [-o file] [flags]")
+	wkspId := fs.String("wksp", *workspaceFlag, "Workspace ID")
+	format := fs.String("format", "json", "Output format: dot, graphml or json")
+	output := fs.String("o", "", "Write the graph to a file instead of stdout")
+	domains := fs.String("d", "", "Comma-separated domains to include (default: every domain in the workspace)")
+	fields := fs.String("fields", "domains,apis,cloud-buckets,bucket-takeovers", "Comma-separated intelligence fields to link JS files to")
+	secrets := fs.Bool("secrets", true, "Link JS files to detected secrets")
+	size := fs.Int("s", 100, "Number of JS files to fetch per domain")
+	positional, err := parseCommandArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) > 0 {
+		return fmt.Errorf("unexpected arguments: %s", strings.Join(positional, " "))
+	}
+	if err := checkGraphFormat(*format); err != nil {
+		return err
+	}
+	var backends []string
+	for _, name := range splitWords(*fields) {
+		backend, err := resolveQueryField(name)
+		if err != nil {
+			return fmt.Errorf("invalid -fields value: %v", err)
+		}
+		backends = append(backends, backend)
+	}
+	if err := requireWorkspace(*wkspId); err != nil {
+		return err
+	}
+
+	domainList := splitWords(*domains)
+	if len(domainList) == 0 {
+		if domainList, err = fetchDomains(*wkspId); err != nil {
+			return fmt.Errorf("error fetching domains: %v", err)
+		}
+	}
+	g, err := buildWorkspaceGraph(domainList, backends, *secrets, *size, *wkspId)
+	if err != nil {
+		return err
+	}
+
+	var w io.Writer = os.Stdout
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			return fmt.Errorf("error creating %s: %v", *output, err)
+		}
+		defer file.Close()
+		w = file
+	}
+	if err := writeGraph(w, g, *format); err != nil {
+		return err
+	}
+	if *output != "" {
+		fmt.Printf("[INF] Wrote %d nodes and %d edges to %s\n", len(g.nodes), len(g.edges), *output)
+	}
+	return nil
+}
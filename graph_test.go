@@ -0,0 +1,129 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+)
+
+// testGraph has an attribute, quotes and XML special characters in labels
+// and one empty label that must be skipped.
+func testGraph() *graph {
+	g := newGraph()
+	domain := g.addNode("domain", "x.com")
+	js := g.addNode("jsfile", `https://x.com/a.js?q="1"&b=<2>`)
+	js.Attrs = map[string]string{"jsmonId": "j&1"}
+	g.addEdge(domain, js, "loads")
+	g.addEdge(js, g.addNode("api", "/api/x"), "contains")
+	g.addEdge(js, g.addNode("api", ""), "contains")
+	g.addEdge(js, g.addNode("email", "  "), "contains")
+	g.addEdge(domain, js, "loads")
+	return g
+}
+
+func TestAddNodeSkipsEmptyLabels(t *testing.T) {
+	g := testGraph()
+	if len(g.nodes) != 3 || len(g.edges) != 2 {
+		t.Errorf("got %d nodes and %d edges, want 3 and 2", len(g.nodes), len(g.edges))
+	}
+	if node := g.addNodeLimit("api", "", 10); node != nil {
+		t.Errorf("got %+v for an empty label", node)
+	}
+}
+
+func TestWriteGraph(t *testing.T) {
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{"json", `{
+  "nodes": [
+    {
+      "id": "domain:x.com",
+      "kind": "domain",
+      "label": "x.com"
+    },
+    {
+      "id": "jsfile:https://x.com/a.js?q=\"1\"\u0026b=\u003c2\u003e",
+      "kind": "jsfile",
+      "label": "https://x.com/a.js?q=\"1\"\u0026b=\u003c2\u003e",
+      "attrs": {
+        "jsmonId": "j\u00261"
+      }
+    },
+    {
+      "id": "api:/api/x",
+      "kind": "api",
+      "label": "/api/x"
+    }
+  ],
+  "edges": [
+    {
+      "from": "domain:x.com",
+      "to": "jsfile:https://x.com/a.js?q=\"1\"\u0026b=\u003c2\u003e",
+      "label": "loads"
+    },
+    {
+      "from": "jsfile:https://x.com/a.js?q=\"1\"\u0026b=\u003c2\u003e",
+      "to": "api:/api/x",
+      "label": "contains"
+    }
+  ]
+}
+`},
+		{"DOT", `digraph jsmon {
+  rankdir=LR;
+  node [style=filled, fillcolor="#ffffff"];
+  "domain:x.com" [label="x.com", shape=box, fillcolor="#cfe2f3"];
+  "jsfile:https://x.com/a.js?q=\"1\"&b=<2>" [label="https://x.com/a.js?q=\"1\"&b=<2>", shape=note, fillcolor="#fff2cc"];
+  "api:/api/x" [label="/api/x", shape=ellipse, fillcolor="#d9ead3"];
+  "domain:x.com" -> "jsfile:https://x.com/a.js?q=\"1\"&b=<2>" [label="loads"];
+  "jsfile:https://x.com/a.js?q=\"1\"&b=<2>" -> "api:/api/x" [label="contains"];
+}
+`},
+		{"graphml", `<?xml version="1.0" encoding="UTF-8"?>
+<graphml xmlns="http://graphml.graphdrawing.org/xmlns">
+  <key id="label" for="node" attr.name="label" attr.type="string"/>
+  <key id="kind" for="node" attr.name="kind" attr.type="string"/>
+  <key id="attr_jsmonId" for="node" attr.name="jsmonId" attr.type="string"/>
+  <key id="edgelabel" for="edge" attr.name="label" attr.type="string"/>
+  <graph id="jsmon" edgedefault="directed">
+    <node id="n0">
+      <data key="label">x.com</data>
+      <data key="kind">domain</data>
+    </node>
+    <node id="n1">
+      <data key="label">https://x.com/a.js?q=&#34;1&#34;&amp;b=&lt;2&gt;</data>
+      <data key="kind">jsfile</data>
+      <data key="attr_jsmonId">j&amp;1</data>
+    </node>
+    <node id="n2">
+      <data key="label">/api/x</data>
+      <data key="kind">api</data>
+    </node>
+    <edge id="e0" source="n0" target="n1">
+      <data key="edgelabel">loads</data>
+    </edge>
+    <edge id="e1" source="n1" target="n2">
+      <data key="edgelabel">contains</data>
+    </edge>
+  </graph>
+</graphml>
+`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			var b bytes.Buffer
+			if err := writeGraph(&b, testGraph(), tt.format); err != nil {
+				t.Fatal(err)
+			}
+			if got := b.String(); got != tt.want {
+				t.Errorf("got\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+
+	var b bytes.Buffer
+	if err := writeGraph(&b, testGraph(), "svg"); err == nil || b.Len() != 0 {
+		t.Errorf("got %v and %q for an unknown format", err, b.String())
+	}
+}
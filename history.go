@@ -0,0 +1,226 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// jsVersion is one stored version of a JS URL. Size and Hash are computed
+// from the stored content by fillVersionContent.
+type jsVersion struct {
+	JsmonId   string `json:"jsmonId"`
+	URL       string `json:"url"`
+	CreatedAt string `json:"createdAt"`
+	Size      int64  `json:"size,omitempty"`
+	Hash      string `json:"hash,omitempty"`
+}
+
+func parseVersionTime(value string) (time.Time, bool) {
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02 15:04:05", "2006-01-02"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// versionsFromResults keeps the results stored for jsURL, oldest first, and
+// returns how many of them had no jsmon ID and were skipped.
+func versionsFromResults(results []IntelligenceResult, jsURL string) ([]jsVersion, int) {
+	var versions []jsVersion
+	skipped := 0
+	seen := map[string]bool{}
+	for _, result := range results {
+		if result.URL != jsURL {
+			continue
+		}
+		if result.JsmonId == "" {
+			skipped++
+			continue
+		}
+		if seen[result.JsmonId] {
+			continue
+		}
+		seen[result.JsmonId] = true
+		versions = append(versions, jsVersion{JsmonId: result.JsmonId, URL: result.URL, CreatedAt: result.CreatedAt})
+	}
+
+	sort.SliceStable(versions, func(i, j int) bool {
+		a, aOK := parseVersionTime(versions[i].CreatedAt)
+		b, bOK := parseVersionTime(versions[j].CreatedAt)
+		if aOK && bOK {
+			return a.Before(b)
+		}
+		return versions[i].CreatedAt < versions[j].CreatedAt
+	})
+	return versions, skipped
+}
+
+// fetchURLHistory returns every stored version of jsURL, oldest first. The
+// automation results are looked up by the URL's domain and then matched on
+// the exact URL.
+func fetchURLHistory(jsURL string, size int, wkspId string) ([]jsVersion, error) {
+	domain := normalizeHost(jsURL)
+	if domain == "" {
+		return nil, fmt.Errorf("invalid URL %s", jsURL)
+	}
+	results, err := fetchIntelligenceResults("domain", domain, nil, size, wkspId)
+	if err != nil {
+		return nil, err
+	}
+	// The results endpoint has no paging, so a full page may leave out older
+	// versions of the URL.
+	if size > 0 && len(results) >= size {
+		fmt.Fprintf(os.Stderr, "[WRN] %s returned the maximum of %d results, older versions of %s may be missing; raise -s to fetch more\n", domain, size, jsURL)
+	}
+	versions, skipped := versionsFromResults(results, jsURL)
+	if skipped > 0 {
+		fmt.Fprintf(os.Stderr, "[WRN] Skipped %d results for %s without a jsmon ID\n", skipped, jsURL)
+	}
+	return versions, nil
+}
+
+// fillVersionContent sets the size and SHA-256 hash of every version from
+// its stored content, which the compare endpoint returns alongside the diff
+// of two consecutive versions.
+func fillVersionContent(versions []jsVersion, wkspId string) error {
+	setContent := func(version *jsVersion, content string) {
+		sum := sha256.Sum256([]byte(content))
+		version.Size = int64(len(content))
+		version.Hash = hex.EncodeToString(sum[:])
+	}
+	if len(versions) == 1 {
+		items, err := compareEndpoint(versions[0].JsmonId, versions[0].JsmonId, wkspId)
+		if err != nil {
+			return err
+		}
+		content, _ := versionSources(items)
+		setContent(&versions[0], content)
+		return nil
+	}
+	for i := 1; i < len(versions); i++ {
+		items, err := compareEndpoint(versions[i-1].JsmonId, versions[i].JsmonId, wkspId)
+		if err != nil {
+			return err
+		}
+		oldContent, newContent := versionSources(items)
+		if i == 1 {
+			setContent(&versions[0], oldContent)
+		}
+		setContent(&versions[i], newContent)
+	}
+	return nil
+}
+
+// resolveVersion finds a version by its 1-based position in the history or by
+// jsmon ID.
+func resolveVersion(versions []jsVersion, ref string) (jsVersion, error) {
+	ref = strings.TrimSpace(ref)
+	if n, err := strconv.Atoi(ref); err == nil && n >= 1 && n <= len(versions) {
+		return versions[n-1], nil
+	}
+	for _, version := range versions {
+		if version.JsmonId == ref {
+			return version, nil
+		}
+	}
+	return jsVersion{}, fmt.Errorf("version %q not found, use a number from the history list or a jsmon ID", ref)
+}
+
+func printURLHistory(jsURL string, versions []jsVersion) {
+	fmt.Printf("History of %s (%d versions)\n", jsURL, len(versions))
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "#\tJSMON ID\tTIMESTAMP\tSIZE\tHASH")
+	for i, version := range versions {
+		size := "-"
+		if version.Size > 0 {
+			size = strconv.FormatInt(version.Size, 10)
+		}
+		hash := "-"
+		if len(version.Hash) >= 12 {
+			hash = version.Hash[:12]
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\n", i+1, version.JsmonId, version.CreatedAt, size, hash)
+	}
+	w.Flush()
+}
+
+func runHistoryCommand(args []string) error {
+	fs := newCommandFlagSet("history", "history <url> [-latest | -diff <a>,<b>] [flags]")
+	wkspId := fs.String("wksp", *workspaceFlag, "Workspace ID")
+	latest := fs.Bool("latest", false, "Diff the latest version against the previous one")
+	diffRefs := fs.String("diff", "", "Diff two versions given as history numbers or jsmon IDs (e.g. 1,3)")
+	jsonOutput := fs.Bool("json", false, "Print the version list as JSON")
+	size := fs.Int("s", 100, "Maximum number of results to fetch for the URL's domain (no paging; raise it if older versions are missing)")
+	var options diffOutputOptions
+	options.register(fs)
+	positional, err := parseCommandArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		fs.Usage()
+		return fmt.Errorf("history needs exactly one URL")
+	}
+	if err := requireWorkspace(*wkspId); err != nil {
+		return err
+	}
+
+	jsURL := strings.TrimSpace(positional[0])
+	versions, err := fetchURLHistory(jsURL, *size, *wkspId)
+	if err != nil {
+		return err
+	}
+	if len(versions) == 0 {
+		return fmt.Errorf("no stored versions found for %s", jsURL)
+	}
+
+	var from, to jsVersion
+	switch {
+	case *latest:
+		if len(versions) < 2 {
+			return fmt.Errorf("%s has only one stored version", jsURL)
+		}
+		from, to = versions[len(versions)-2], versions[len(versions)-1]
+	case *diffRefs != "":
+		refs := strings.Split(*diffRefs, ",")
+		if len(refs) != 2 {
+			return fmt.Errorf("-diff needs two versions separated by a comma, e.g. 1,3")
+		}
+		if from, err = resolveVersion(versions, refs[0]); err != nil {
+			return err
+		}
+		if to, err = resolveVersion(versions, refs[1]); err != nil {
+			return err
+		}
+	default:
+		if err := fillVersionContent(versions, *wkspId); err != nil {
+			return err
+		}
+		if *jsonOutput {
+			jsonData, err := json.MarshalIndent(versions, "", "  ")
+			if err != nil {
+				return fmt.Errorf("error formatting JSON: %v", err)
+			}
+			fmt.Println(string(jsonData))
+			return nil
+		}
+		printURLHistory(jsURL, versions)
+		return nil
+	}
+
+	items, err := compareEndpoint(from.JsmonId, to.JsmonId, *wkspId)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("[INF] Comparing %s (%s) with %s (%s)\n", from.JsmonId, from.CreatedAt, to.JsmonId, to.CreatedAt)
+	return renderDiff(items, from.JsmonId, to.JsmonId, options)
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestVersionsFromResults(t *testing.T) {
+	jsURL := "https://example.com/static/main.js"
+	results := []IntelligenceResult{
+		{JsmonId: "b", URL: jsURL, CreatedAt: "2024-03-02T10:00:00Z"},
+		{JsmonId: "x", URL: "https://example.com/static/other.js", CreatedAt: "2024-03-01T10:00:00Z"},
+		{URL: jsURL, CreatedAt: "2024-03-03T10:00:00Z"},
+		{JsmonId: "a", URL: jsURL, CreatedAt: "2024-03-01T10:00:00Z"},
+		{JsmonId: "b", URL: jsURL, CreatedAt: "2024-03-02T10:00:00Z"},
+	}
+	versions, skipped := versionsFromResults(results, jsURL)
+	want := []jsVersion{
+		{JsmonId: "a", URL: jsURL, CreatedAt: "2024-03-01T10:00:00Z"},
+		{JsmonId: "b", URL: jsURL, CreatedAt: "2024-03-02T10:00:00Z"},
+	}
+	if !reflect.DeepEqual(versions, want) {
+		t.Errorf("got %+v, want %+v", versions, want)
+	}
+	if skipped != 1 {
+		t.Errorf("got %d skipped results, want 1", skipped)
+	}
+}
+
+func TestResolveVersion(t *testing.T) {
+	versions := []jsVersion{{JsmonId: "a"}, {JsmonId: "b"}}
+	for ref, want := range map[string]string{"1": "a", " 2 ": "b", "b": "b"} {
+		version, err := resolveVersion(versions, ref)
+		if err != nil || version.JsmonId != want {
+			t.Errorf("resolveVersion(%q) = %+v, %v, want %s", ref, version, err, want)
+		}
+	}
+	if _, err := resolveVersion(versions, "3"); err == nil {
+		t.Error("got no error for an out of range version")
+	}
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// fetchAutomationResults calls getAllAutomationResults and returns the entries
+// of its "results" array. A size of 0 leaves the server default.
+func fetchAutomationResults(inputType, input, showonly string, size int, wkspId string) ([]json.RawMessage, error) {
+	params := url.Values{}
+	params.Set("inputType", inputType)
+	params.Set("input", input)
+	params.Set("showonly", showonly)
+	params.Set("wkspId", wkspId)
+	if size > 0 {
+		params.Set("size", fmt.Sprintf("%d", size))
+	}
+	endpoint := fmt.Sprintf("%s/getAllAutomationResults?%s", apiBaseURL, params.Encode())
+
+	req, err := http.NewRequest("GET", endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Jsmon-Key", strings.TrimSpace(getAPIKey()))
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %v", err)
+	}
+	if resp.StatusCode == http.StatusUnauthorized {
+		return nil, fmt.Errorf("wrong API key")
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("received status code %d: %s", resp.StatusCode, string(body))
+	}
+
+	var result struct {
+		Message string            `json:"message"`
+		Results []json.RawMessage `json:"results"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("error parsing JSON: %v", err)
+	}
+	if result.Results == nil && result.Message != "" {
+		return nil, fmt.Errorf("%s", result.Message)
+	}
+	return result.Results, nil
+}
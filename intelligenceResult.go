@@ -0,0 +1,266 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// flexList is a list of extracted values. The API returns most of them as
+// strings but some fields (vulnerabilities, domain status, exposures) hold
+// objects, so each entry is kept as raw JSON.
+type flexList []json.RawMessage
+
+func (l *flexList) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	switch {
+	case bytes.Equal(data, []byte("null")):
+		*l = nil
+	case len(data) > 0 && data[0] == '[':
+		var items []json.RawMessage
+		if err := json.Unmarshal(data, &items); err != nil {
+			return err
+		}
+		*l = items
+	default:
+		*l = flexList{append(json.RawMessage(nil), data...)}
+	}
+	return nil
+}
+
+// Strings renders each value as text: strings as they are, objects by their
+// most descriptive key or as compact JSON.
+func (l flexList) Strings() []string {
+	values := make([]string, 0, len(l))
+	for _, raw := range l {
+		values = append(values, flexValueString(raw))
+	}
+	return values
+}
+
+func flexValueString(raw json.RawMessage) string {
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return s
+	}
+	var object map[string]interface{}
+	if err := json.Unmarshal(raw, &object); err == nil {
+		for _, key := range []string{"value", "url", "domain", "name", "email", "path", "query"} {
+			if v, ok := object[key].(string); ok && v != "" {
+				return v
+			}
+		}
+	}
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, raw); err != nil {
+		return string(raw)
+	}
+	return compact.String()
+}
+
+// IntelligenceResult is one JS file's entry in getAllAutomationResults. It has
+// a field for every backend name in fieldMapping; keys it does not know are
+// kept in Extra so printing a result does not drop them.
+type IntelligenceResult struct {
+	JsmonId   string `json:"jsmonId,omitempty"`
+	FileId    string `json:"fileId,omitempty"`
+	URL       string `json:"url,omitempty"`
+	CreatedAt string `json:"createdAt,omitempty"`
+
+	ExtractedUrls          flexList `json:"extractedUrls,omitempty"`
+	ExtractedDomains       flexList `json:"extractedDomains,omitempty"`
+	ExtractedDomainsStatus flexList `json:"extractedDomainsStatus,omitempty"`
+	Ipv4Addresses          flexList `json:"ipv4Addresses,omitempty"`
+	Ipv6Addresses          flexList `json:"ipv6Addresses,omitempty"`
+	Emails                 flexList `json:"emails,omitempty"`
+	S3Domains              flexList `json:"s3Domains,omitempty"`
+	InvalidS3Domains       flexList `json:"invalidS3Domains,omitempty"`
+	ApiPaths               flexList `json:"apiPaths,omitempty"`
+	GqlQuery               flexList `json:"gqlQuery,omitempty"`
+	GqlMutation            flexList `json:"gqlMutation,omitempty"`
+	GqlFragment            flexList `json:"gqlFragment,omitempty"`
+	ValidNodeModules       flexList `json:"validNodeModules,omitempty"`
+	InvalidNodeModules     flexList `json:"invalidNodeModules,omitempty"`
+	Vulnerabilities        flexList `json:"vulnerabilities,omitempty"`
+	Guids                  flexList `json:"guids,omitempty"`
+	QueryParamsUrls        flexList `json:"queryParamsUrls,omitempty"`
+	SocialMediaUrls        flexList `json:"socialMediaUrls,omitempty"`
+	LocalhostUrls          flexList `json:"localhostUrls,omitempty"`
+	FilteredPortUrls       flexList `json:"filteredPortUrls,omitempty"`
+	Exposures              flexList `json:"exposures,omitempty"`
+	JsUrls                 flexList `json:"jsUrls,omitempty"`
+
+	Extra map[string]json.RawMessage `json:"-"`
+}
+
+// intelligenceResultFields avoids recursing into the custom (un)marshalers.
+type intelligenceResultFields IntelligenceResult
+
+func (r *IntelligenceResult) UnmarshalJSON(data []byte) error {
+	var fields intelligenceResultFields
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	var all map[string]json.RawMessage
+	if err := json.Unmarshal(data, &all); err != nil {
+		return err
+	}
+	for _, key := range []string{"jsmonId", "fileId", "url", "createdAt"} {
+		delete(all, key)
+	}
+	for _, backend := range fieldMapping {
+		delete(all, backend)
+	}
+	*r = IntelligenceResult(fields)
+	if len(all) > 0 {
+		r.Extra = all
+	}
+	return nil
+}
+
+func (r IntelligenceResult) MarshalJSON() ([]byte, error) {
+	data, err := json.Marshal(intelligenceResultFields(r))
+	if err != nil || len(r.Extra) == 0 {
+		return data, err
+	}
+	var all map[string]json.RawMessage
+	if err := json.Unmarshal(data, &all); err != nil {
+		return nil, err
+	}
+	for key, value := range r.Extra {
+		if _, known := all[key]; !known {
+			all[key] = value
+		}
+	}
+	return json.Marshal(all)
+}
+
+// Field returns the values of a backend field such as "apiPaths", or nil if
+// the name is not a known field.
+func (r *IntelligenceResult) Field(backend string) flexList {
+	switch backend {
+	case "extractedUrls":
+		return r.ExtractedUrls
+	case "extractedDomains":
+		return r.ExtractedDomains
+	case "extractedDomainsStatus":
+		return r.ExtractedDomainsStatus
+	case "ipv4Addresses":
+		return r.Ipv4Addresses
+	case "ipv6Addresses":
+		return r.Ipv6Addresses
+	case "emails":
+		return r.Emails
+	case "s3Domains":
+		return r.S3Domains
+	case "invalidS3Domains":
+		return r.InvalidS3Domains
+	case "apiPaths":
+		return r.ApiPaths
+	case "gqlQuery":
+		return r.GqlQuery
+	case "gqlMutation":
+		return r.GqlMutation
+	case "gqlFragment":
+		return r.GqlFragment
+	case "validNodeModules":
+		return r.ValidNodeModules
+	case "invalidNodeModules":
+		return r.InvalidNodeModules
+	case "vulnerabilities":
+		return r.Vulnerabilities
+	case "guids":
+		return r.Guids
+	case "queryParamsUrls":
+		return r.QueryParamsUrls
+	case "socialMediaUrls":
+		return r.SocialMediaUrls
+	case "localhostUrls":
+		return r.LocalhostUrls
+	case "filteredPortUrls":
+		return r.FilteredPortUrls
+	case "exposures":
+		return r.Exposures
+	case "jsUrls":
+		return r.JsUrls
+	}
+	return nil
+}
+
+// parseShowFields converts a -show list of field names ("apis,emails") into
+// backend names.
+func parseShowFields(show string) ([]string, error) {
+	var backends []string
+	for _, name := range splitWords(show) {
+		backend, err := resolveQueryField(name)
+		if err != nil {
+			return nil, fmt.Errorf("invalid -show value: %v", err)
+		}
+		backends = append(backends, backend)
+	}
+	return backends, nil
+}
+
+// fetchIntelligenceResults fetches automation results for an input and
+// decodes them. With show set only those backend fields are requested.
+func fetchIntelligenceResults(inputType, input string, show []string, size int, wkspId string) ([]IntelligenceResult, error) {
+	showonly := "all"
+	if len(show) > 0 {
+		showonly = strings.Join(show, ",")
+	}
+	raw, err := fetchAutomationResults(inputType, input, showonly, size, wkspId)
+	if err != nil {
+		return nil, err
+	}
+	results := make([]IntelligenceResult, 0, len(raw))
+	for _, item := range raw {
+		var result IntelligenceResult
+		if err := json.Unmarshal(item, &result); err != nil {
+			return nil, fmt.Errorf("error parsing result: %v", err)
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// printIntelligenceResults prints every result as JSON, or with show set,
+// the selected fields of each result as lists.
+func printIntelligenceResults(results []IntelligenceResult, show []string) error {
+	if len(results) == 0 {
+		fmt.Println("No results found")
+		return nil
+	}
+	if len(show) == 0 {
+		for _, result := range results {
+			prettyJSON, err := json.MarshalIndent(result, "", "  ")
+			if err != nil {
+				return fmt.Errorf("error formatting JSON: %v", err)
+			}
+			fmt.Println(string(prettyJSON))
+		}
+		return nil
+	}
+
+	friendly := map[string]string{}
+	for name, backend := range fieldMapping {
+		friendly[backend] = name
+	}
+	for _, result := range results {
+		if result.JsmonId != "" {
+			fmt.Printf("[%s] %s\n", result.JsmonId, result.URL)
+		} else {
+			fmt.Println(result.URL)
+		}
+		for _, backend := range show {
+			values := result.Field(backend).Strings()
+			sort.Strings(values)
+			fmt.Printf("  %s (%d):\n", friendly[backend], len(values))
+			for _, value := range values {
+				fmt.Printf("    %s\n", value)
+			}
+		}
+	}
+	return nil
+}
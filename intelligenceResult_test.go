@@ -0,0 +1,72 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestFlexList(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want []string
+	}{
+		{"strings", `["https://a.com/x","/api/y"]`, []string{"https://a.com/x", "/api/y"}},
+		{"numbers", `[1, 2.5]`, []string{"1", "2.5"}},
+		{"objects", `[{"url":"https://a.com","status":200},{"name":"CVE-1"},{"a": 1}]`, []string{"https://a.com", "CVE-1", `{"a":1}`}},
+		{"single string", `"one"`, []string{"one"}},
+		{"single number", `42`, []string{"42"}},
+		{"null", `null`, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var l flexList
+			if err := json.Unmarshal([]byte(tt.data), &l); err != nil {
+				t.Fatal(err)
+			}
+			if got := l.Strings(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIntelligenceResultExtra(t *testing.T) {
+	data := `{"jsmonId":"j1","url":"https://a.com/main.js","apiPaths":["/api/x"],"vulnerabilities":[{"name":"CVE-1","severity":"high"}],"newField":{"k":[1,2]},"count":3}`
+	var result IntelligenceResult
+	if err := json.Unmarshal([]byte(data), &result); err != nil {
+		t.Fatal(err)
+	}
+	if result.JsmonId != "j1" || !reflect.DeepEqual(result.Field("apiPaths").Strings(), []string{"/api/x"}) {
+		t.Errorf("got %+v", result)
+	}
+	if got := result.Field("vulnerabilities").Strings(); !reflect.DeepEqual(got, []string{"CVE-1"}) {
+		t.Errorf("got vulnerabilities %q", got)
+	}
+	if len(result.Extra) != 2 || string(result.Extra["newField"]) != `{"k":[1,2]}` || string(result.Extra["count"]) != "3" {
+		t.Errorf("got Extra %v", result.Extra)
+	}
+
+	encoded, err := json.Marshal(result)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got, want map[string]interface{}
+	if err := json.Unmarshal(encoded, &got); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal([]byte(data), &want); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("round trip got %s, want %s", encoded, data)
+	}
+
+	// Extra never overrides a known field.
+	result.Extra["url"] = json.RawMessage(`"https://evil.com"`)
+	encoded, _ = json.Marshal(result)
+	if err := json.Unmarshal(encoded, &got); err != nil || got["url"] != "https://a.com/main.js" {
+		t.Errorf("got %s", encoded)
+	}
+}
@@ -0,0 +1,209 @@
+package main
+
+import (
+	"strings"
+)
+
+// spacedOperators get a space on both sides when beautifying.
+var spacedOperators = map[string]bool{
+	"=": true, "==": true, "===": true, "!=": true, "!==": true, "<": true, ">": true,
+	"<=": true, ">=": true, "+=": true, "-=": true, "*=": true, "/=": true, "%=": true,
+	"**=": true, "<<=": true, ">>=": true, ">>>=": true, "&=": true, "|=": true, "^=": true,
+	"&&": true, "||": true, "??": true, "&&=": true, "||=": true, "??=": true, "=>": true,
+	"?": true, "|": true, "&": true, "^": true, "<<": true, ">>": true, ">>>": true,
+	"*": true, "**": true, "/": true, "%": true,
+}
+
+// blockContinuations are keywords that stay on the same line as a closing brace.
+var blockContinuations = map[string]bool{
+	"else": true, "catch": true, "finally": true, "while": true,
+	"instanceof": true, "in": true, "of": true,
+}
+
+var spacedKeywordParens = map[string]bool{
+	"if": true, "for": true, "while": true, "switch": true, "catch": true, "with": true,
+}
+
+// beautifyJS pretty-prints JavaScript source: one statement per line, blocks
+// indented by two spaces and spaces around operators. Line breaks from the
+// original source are kept so automatic semicolon insertion still applies.
+func beautifyJS(src string) string {
+	return formatJSTokens(tokenizeJS(src))
+}
+
+type jsPrinter struct {
+	b           strings.Builder
+	indent      int
+	atLineStart bool
+}
+
+func (p *jsPrinter) newline() {
+	if !p.atLineStart {
+		p.b.WriteByte('\n')
+		p.atLineStart = true
+	}
+}
+
+func (p *jsPrinter) write(s string) {
+	if p.atLineStart {
+		p.b.WriteString(strings.Repeat("  ", p.indent))
+		p.atLineStart = false
+	}
+	p.b.WriteString(s)
+}
+
+// startsStatement reports whether t begins a new statement after a closing
+// brace and therefore goes on its own line.
+func startsStatement(t jsToken) bool {
+	switch t.Kind {
+	case jsIdent:
+		return !blockContinuations[t.Value]
+	case jsString, jsNumber, jsTemplate:
+		return true
+	case jsPunct:
+		return t.Value == "{" || t.Value == "}" || t.Value == "!"
+	}
+	return false
+}
+
+func formatJSTokens(tokens []jsToken) string {
+	p := &jsPrinter{atLineStart: true}
+	// brackets holds the currently open (, [ and { tokens.
+	var brackets []string
+	inBracket := func(open string) bool {
+		return len(brackets) > 0 && brackets[len(brackets)-1] == open
+	}
+	signIsBinary := false
+	var prev *jsToken
+
+	for i := 0; i < len(tokens); i++ {
+		t := tokens[i]
+		if t.NewlineBefore && prev != nil {
+			p.newline()
+		}
+
+		if t.Kind == jsComment {
+			if !p.atLineStart {
+				p.write(" ")
+			}
+			p.write(t.Value)
+			if strings.HasPrefix(t.Value, "//") {
+				p.newline()
+			}
+			continue
+		}
+
+		if !p.atLineStart && prev != nil && needsSpace(*prev, t, signIsBinary) {
+			p.write(" ")
+		}
+		if t.Kind == jsPunct && (t.Value == "+" || t.Value == "-") {
+			signIsBinary = prev != nil && isBinaryContext(*prev)
+		}
+
+		switch {
+		case t.Kind == jsPunct && t.Value == "{":
+			if i+1 < len(tokens) && tokens[i+1].Value == "}" && tokens[i+1].Kind == jsPunct {
+				p.write("{}")
+				i++
+				t = tokens[i]
+				if i+1 < len(tokens) && startsStatement(tokens[i+1]) {
+					p.newline()
+				}
+				break
+			}
+			brackets = append(brackets, "{")
+			p.write("{")
+			p.indent++
+			p.newline()
+		case t.Kind == jsPunct && t.Value == "}":
+			if inBracket("{") {
+				brackets = brackets[:len(brackets)-1]
+			}
+			if p.indent > 0 {
+				p.indent--
+			}
+			p.newline()
+			p.write("}")
+			if i+1 < len(tokens) && startsStatement(tokens[i+1]) {
+				p.newline()
+			}
+		case t.Kind == jsPunct && t.Value == ";":
+			p.write(";")
+			if !inBracket("(") {
+				p.newline()
+			}
+		case t.Kind == jsPunct && t.Value == ",":
+			p.write(",")
+			if inBracket("{") {
+				p.newline()
+			}
+		case t.Kind == jsPunct && (t.Value == "(" || t.Value == "["):
+			brackets = append(brackets, t.Value)
+			p.write(t.Value)
+		case t.Kind == jsPunct && (t.Value == ")" || t.Value == "]"):
+			if len(brackets) > 0 && brackets[len(brackets)-1] != "{" {
+				brackets = brackets[:len(brackets)-1]
+			}
+			p.write(t.Value)
+		default:
+			p.write(t.Value)
+		}
+		prev = &tokens[i]
+	}
+	p.newline()
+	return p.b.String()
+}
+
+func isWordToken(t jsToken) bool {
+	return t.Kind == jsIdent || t.Kind == jsNumber || t.Kind == jsRegex ||
+		t.Kind == jsString || t.Kind == jsTemplate
+}
+
+// isBinaryContext reports whether a + or - following prev is a binary
+// operator rather than a unary sign.
+func isBinaryContext(prev jsToken) bool {
+	switch prev.Kind {
+	case jsIdent:
+		return !regexPrecedingKeywords[prev.Value]
+	case jsNumber, jsString, jsTemplate, jsRegex:
+		return true
+	case jsPunct:
+		return prev.Value == ")" || prev.Value == "]" || prev.Value == "}"
+	}
+	return false
+}
+
+// needsSpace decides whether a space separates prev and t. Spaces that keep
+// tokens from merging ("a - -b", "x / /re/") are always added. signIsBinary
+// tells whether prev, when it is + or -, was used as a binary operator.
+func needsSpace(prev, t jsToken, signIsBinary bool) bool {
+	if prev.Kind == jsPunct && t.Kind == jsPunct || prev.Kind == jsPunct && t.Kind == jsRegex {
+		last := prev.Value[len(prev.Value)-1]
+		first := t.Value[0]
+		if (last == '+' || last == '-') && first == last || last == '/' && (first == '/' || first == '*') {
+			return true
+		}
+	}
+
+	switch {
+	case isWordToken(prev) && isWordToken(t):
+		return true
+	case (prev.Value == ")" || prev.Value == "}") && prev.Kind == jsPunct && isWordToken(t):
+		return true
+	case t.Kind == jsPunct && t.Value == "(" && prev.Kind == jsIdent:
+		return spacedKeywordParens[prev.Value]
+	case t.Kind == jsPunct && t.Value == "{":
+		return prev.Value != "(" && prev.Value != "["
+	case prev.Kind == jsPunct && (prev.Value == "," || prev.Value == ":" || prev.Value == ";"):
+		return true
+	case t.Kind == jsPunct && (t.Value == "+" || t.Value == "-"):
+		return isBinaryContext(prev)
+	case prev.Kind == jsPunct && (prev.Value == "+" || prev.Value == "-"):
+		return signIsBinary
+	case t.Kind == jsPunct && spacedOperators[t.Value]:
+		return true
+	case prev.Kind == jsPunct && spacedOperators[prev.Value]:
+		return true
+	}
+	return false
+}
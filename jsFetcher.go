@@ -0,0 +1,100 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// jsResource is a fetched JS file or source map.
+type jsResource struct {
+	Ref    string
+	Body   []byte
+	Header http.Header
+}
+
+// jsFetcher loads JS files and source maps by reference: a local path or an
+// http(s) URL. Commands take a jsFetcher so they can run against local
+// fixtures or a stub server.
+type jsFetcher interface {
+	fetch(ref string) (jsResource, error)
+}
+
+// httpJSFetcher reads local paths from disk and fetches URLs with the given
+// "Key: Value" headers.
+type httpJSFetcher struct {
+	client  *http.Client
+	headers []string
+}
+
+func newJSFetcher(headers []string) jsFetcher {
+	return &httpJSFetcher{client: &http.Client{}, headers: headers}
+}
+
+func isHTTPRef(ref string) bool {
+	return strings.HasPrefix(ref, "http://") || strings.HasPrefix(ref, "https://")
+}
+
+func (f *httpJSFetcher) fetch(ref string) (jsResource, error) {
+	if !isHTTPRef(ref) {
+		data, err := os.ReadFile(ref)
+		if err != nil {
+			return jsResource{}, fmt.Errorf("error reading %s: %v", ref, err)
+		}
+		return jsResource{Ref: ref, Body: data}, nil
+	}
+
+	req, err := http.NewRequest("GET", ref, nil)
+	if err != nil {
+		return jsResource{}, fmt.Errorf("error creating request for %s: %v", ref, err)
+	}
+	for _, header := range f.headers {
+		parts := strings.SplitN(header, ":", 2)
+		if len(parts) == 2 {
+			req.Header.Set(strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1]))
+		}
+	}
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return jsResource{}, fmt.Errorf("error fetching %s: %v", ref, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return jsResource{}, fmt.Errorf("error fetching %s: status code %d", ref, resp.StatusCode)
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return jsResource{}, fmt.Errorf("error reading %s: %v", ref, err)
+	}
+	return jsResource{Ref: ref, Body: data, Header: resp.Header}, nil
+}
+
+// resolveJSRef resolves ref relative to the JS file or map at base, which is
+// a URL or a local path. Absolute URLs and data URIs are returned unchanged.
+func resolveJSRef(base, ref string) (string, error) {
+	if isHTTPRef(ref) || strings.HasPrefix(ref, "data:") {
+		return ref, nil
+	}
+	if isHTTPRef(base) {
+		baseURL, err := url.Parse(base)
+		if err != nil {
+			return "", fmt.Errorf("invalid URL %s: %v", base, err)
+		}
+		refURL, err := url.Parse(ref)
+		if err != nil {
+			return "", fmt.Errorf("invalid reference %s: %v", ref, err)
+		}
+		return baseURL.ResolveReference(refURL).String(), nil
+	}
+	if i := strings.IndexAny(ref, "?#"); i >= 0 {
+		ref = ref[:i]
+	}
+	if filepath.IsAbs(ref) {
+		return ref, nil
+	}
+	return filepath.Join(filepath.Dir(base), filepath.FromSlash(ref)), nil
+}
@@ -0,0 +1,319 @@
+package main
+
+import (
+	"strings"
+)
+
+type jsTokenKind int
+
+const (
+	jsIdent jsTokenKind = iota
+	jsNumber
+	jsString
+	jsTemplate
+	jsRegex
+	jsPunct
+	jsComment
+)
+
+// jsToken is a lexical token of a JavaScript source. Value holds the raw source
+// text; for strings and templates Text holds the decoded content.
+type jsToken struct {
+	Kind  jsTokenKind
+	Value string
+	Text  string
+	Line  int
+	// NewlineBefore is set when a line break separates the token from the
+	// previous one, which matters for automatic semicolon insertion.
+	NewlineBefore bool
+}
+
+// regexPrecedingKeywords are keywords after which a slash starts a regular
+// expression rather than a division.
+var regexPrecedingKeywords = map[string]bool{
+	"return": true, "typeof": true, "instanceof": true, "in": true, "of": true,
+	"new": true, "delete": true, "void": true, "throw": true, "case": true,
+	"do": true, "else": true, "yield": true, "await": true,
+}
+
+// jsPunctuators lists multi-character punctuators longest first.
+var jsPunctuators = []string{
+	">>>=", "...", "===", "!==", "**=", "<<=", ">>=", ">>>", "&&=", "||=", "??=",
+	"=>", "==", "!=", "<=", ">=", "&&", "||", "??", "?.", "++", "--", "+=", "-=",
+	"*=", "/=", "%=", "&=", "|=", "^=", "<<", ">>", "**",
+}
+
+// tokenizeJS splits JavaScript source into tokens. It is a tolerant lexer for
+// analysis, not a validator: malformed input never fails, it just produces
+// best-effort tokens.
+func tokenizeJS(src string) []jsToken {
+	var tokens []jsToken
+	line := 1
+	newline := false
+	i := 0
+	for i < len(src) {
+		c := src[i]
+
+		if c == '\n' {
+			line++
+			newline = true
+			i++
+			continue
+		}
+		if c == ' ' || c == '\t' || c == '\r' || c == '\f' || c == '\v' {
+			i++
+			continue
+		}
+
+		start := i
+		startLine := line
+		var kind jsTokenKind
+		text := ""
+
+		switch {
+		case c == '/' && i+1 < len(src) && src[i+1] == '/':
+			end := strings.IndexByte(src[i:], '\n')
+			if end < 0 {
+				end = len(src) - i
+			}
+			i += end
+			kind = jsComment
+		case c == '/' && i+1 < len(src) && src[i+1] == '*':
+			end := strings.Index(src[i+2:], "*/")
+			if end < 0 {
+				i = len(src)
+			} else {
+				i += end + 4
+			}
+			line += strings.Count(src[start:i], "\n")
+			kind = jsComment
+		case c == '"' || c == '\'':
+			i, text = scanJSString(src, i)
+			line += strings.Count(src[start:i], "\n")
+			kind = jsString
+		case c == '`':
+			i, text = scanJSTemplate(src, i)
+			line += strings.Count(src[start:i], "\n")
+			kind = jsTemplate
+		case c == '/' && slashStartsRegex(tokens):
+			i = scanJSRegex(src, i)
+			kind = jsRegex
+		case isJSIdentStart(c):
+			for i < len(src) && isJSIdentPart(src[i]) {
+				i++
+			}
+			kind = jsIdent
+		case c >= '0' && c <= '9' || c == '.' && i+1 < len(src) && src[i+1] >= '0' && src[i+1] <= '9':
+			i++
+			for i < len(src) && (isJSIdentPart(src[i]) || src[i] == '.' ||
+				(src[i] == '+' || src[i] == '-') && (src[i-1] == 'e' || src[i-1] == 'E') && !strings.HasPrefix(src[start:], "0x")) {
+				i++
+			}
+			kind = jsNumber
+		default:
+			kind = jsPunct
+			i++
+			for _, p := range jsPunctuators {
+				if strings.HasPrefix(src[start:], p) {
+					i = start + len(p)
+					break
+				}
+			}
+		}
+
+		tokens = append(tokens, jsToken{
+			Kind:          kind,
+			Value:         src[start:i],
+			Text:          text,
+			Line:          startLine,
+			NewlineBefore: newline,
+		})
+		newline = false
+	}
+	return tokens
+}
+
+func isJSIdentStart(c byte) bool {
+	return c == '_' || c == '$' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= 0x80
+}
+
+func isJSIdentPart(c byte) bool {
+	return isJSIdentStart(c) || c >= '0' && c <= '9'
+}
+
+// slashStartsRegex decides from the previous significant token whether a
+// slash begins a regular expression literal.
+func slashStartsRegex(tokens []jsToken) bool {
+	for i := len(tokens) - 1; i >= 0; i-- {
+		prev := tokens[i]
+		switch prev.Kind {
+		case jsComment:
+			continue
+		case jsIdent:
+			return regexPrecedingKeywords[prev.Value]
+		case jsPunct:
+			return prev.Value != ")" && prev.Value != "]" && prev.Value != "}"
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// scanJSString scans a quoted string starting at i and returns the index after
+// it and its decoded content.
+func scanJSString(src string, i int) (int, string) {
+	quote := src[i]
+	var b strings.Builder
+	i++
+	for i < len(src) {
+		c := src[i]
+		if c == quote {
+			return i + 1, b.String()
+		}
+		if c == '\n' {
+			return i, b.String()
+		}
+		if c == '\\' && i+1 < len(src) {
+			i = decodeJSEscape(src, i, &b)
+			continue
+		}
+		b.WriteByte(c)
+		i++
+	}
+	return i, b.String()
+}
+
+// scanJSTemplate scans a template literal. Substitutions are kept as ${...} in
+// the returned text, with nested braces, strings and templates skipped.
+func scanJSTemplate(src string, i int) (int, string) {
+	var b strings.Builder
+	i++
+	for i < len(src) {
+		c := src[i]
+		switch {
+		case c == '`':
+			return i + 1, b.String()
+		case c == '\\' && i+1 < len(src):
+			i = decodeJSEscape(src, i, &b)
+		case c == '$' && i+1 < len(src) && src[i+1] == '{':
+			start := i
+			depth := 0
+			i++
+			for i < len(src) {
+				switch src[i] {
+				case '{':
+					depth++
+				case '}':
+					depth--
+				case '"', '\'':
+					i, _ = scanJSString(src, i)
+					continue
+				case '`':
+					i, _ = scanJSTemplate(src, i)
+					continue
+				}
+				i++
+				if depth == 0 {
+					break
+				}
+			}
+			b.WriteString(src[start:i])
+		default:
+			b.WriteByte(c)
+			i++
+		}
+	}
+	return i, b.String()
+}
+
+func decodeJSEscape(src string, i int, b *strings.Builder) int {
+	next := src[i+1]
+	switch next {
+	case 'n':
+		b.WriteByte('\n')
+	case 't':
+		b.WriteByte('\t')
+	case 'r':
+		b.WriteByte('\r')
+	case '\n':
+	case 'x':
+		if i+3 < len(src) {
+			if v, ok := parseHex(src[i+2 : i+4]); ok {
+				b.WriteRune(rune(v))
+				return i + 4
+			}
+		}
+		b.WriteByte(next)
+	case 'u':
+		if i+2 < len(src) && src[i+2] == '{' {
+			if end := strings.IndexByte(src[i:], '}'); end > 3 {
+				if v, ok := parseHex(src[i+3 : i+end]); ok {
+					b.WriteRune(rune(v))
+					return i + end + 1
+				}
+			}
+		} else if i+5 < len(src) {
+			if v, ok := parseHex(src[i+2 : i+6]); ok {
+				b.WriteRune(rune(v))
+				return i + 6
+			}
+		}
+		b.WriteByte(next)
+	default:
+		b.WriteByte(next)
+	}
+	return i + 2
+}
+
+func parseHex(s string) (int, bool) {
+	if s == "" || len(s) > 6 {
+		return 0, false
+	}
+	v := 0
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c >= '0' && c <= '9':
+			v = v*16 + int(c-'0')
+		case c >= 'a' && c <= 'f':
+			v = v*16 + int(c-'a'+10)
+		case c >= 'A' && c <= 'F':
+			v = v*16 + int(c-'A'+10)
+		default:
+			return 0, false
+		}
+	}
+	return v, true
+}
+
+// scanJSRegex scans a regular expression literal including its flags.
+func scanJSRegex(src string, i int) int {
+	inClass := false
+	i++
+	for i < len(src) {
+		c := src[i]
+		switch {
+		case c == '\\':
+			i += 2
+			if i > len(src) {
+				return len(src)
+			}
+			continue
+		case c == '\n':
+			return i
+		case c == '[':
+			inClass = true
+		case c == ']':
+			inClass = false
+		case c == '/' && !inClass:
+			i++
+			for i < len(src) && isJSIdentPart(src[i]) {
+				i++
+			}
+			return i
+		}
+		i++
+	}
+	return i
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// jwtToken is a decoded JSON Web Token. The signature is not verified.
+type jwtToken struct {
+	Raw       string                 `json:"-"`
+	Header    map[string]interface{} `json:"header"`
+	Claims    map[string]interface{} `json:"claims"`
+	Signature string                 `json:"-"`
+}
+
+func decodeJWTSegment(segment string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(segment, "="))
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// decodeJWT decodes the header and claims of a compact JWT.
+func decodeJWT(token string) (jwtToken, error) {
+	token = strings.TrimSpace(token)
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return jwtToken{}, fmt.Errorf("expected 3 parts, got %d", len(parts))
+	}
+	jwt := jwtToken{Raw: token, Signature: parts[2]}
+	if err := decodeJWTSegment(parts[0], &jwt.Header); err != nil {
+		return jwtToken{}, fmt.Errorf("invalid header: %v", err)
+	}
+	if err := decodeJWTSegment(parts[1], &jwt.Claims); err != nil {
+		return jwtToken{}, fmt.Errorf("invalid claims: %v", err)
+	}
+	return jwt, nil
+}
+
+func (t jwtToken) alg() string {
+	alg, _ := t.Header["alg"].(string)
+	return alg
+}
+
+// timeClaim returns a NumericDate claim such as exp, iat or nbf.
+func (t jwtToken) timeClaim(name string) (time.Time, bool) {
+	switch v := t.Claims[name].(type) {
+	case float64:
+		return time.Unix(int64(v), 0).UTC(), true
+	case string:
+		var seconds float64
+		if _, err := fmt.Sscanf(v, "%f", &seconds); err == nil {
+			return time.Unix(int64(seconds), 0).UTC(), true
+		}
+	}
+	return time.Time{}, false
+}
+
+// weakJWTSecrets are HMAC keys tried against HS256/384/512 tokens: defaults
+// from documentation and tutorials that end up in production.
+var weakJWTSecrets = []string{
+	"secret", "your-256-bit-secret", "your-384-bit-secret", "your-512-bit-secret",
+	"secretkey", "secret-key", "jwt_secret", "jwtsecret", "changeme", "password",
+	"123456", "key", "test", "dev", "shhhhh", "mysecret", "supersecret",
+}
+
+// sensitiveClaimPattern matches claim names that should not be readable by
+// anyone holding the token.
+var sensitiveClaimPattern = regexp.MustCompile(`(?i)(pass(word)?|pwd|secret|api_?key|private|token|ssn|credit|card|cvv|iban|dob|phone|address)`)
+
+// jwtMaxLifetime is the token lifetime above which expiry is reported as long.
+const jwtMaxLifetime = 30 * 24 * time.Hour
+
+// jwtReport is the inspection of one token and the JS files it was found in.
+type jwtReport struct {
+	Token      string                 `json:"token"`
+	Alg        string                 `json:"alg"`
+	Header     map[string]interface{} `json:"header,omitempty"`
+	Claims     map[string]interface{} `json:"claims,omitempty"`
+	IssuedAt   string                 `json:"issuedAt,omitempty"`
+	ExpiresAt  string                 `json:"expiresAt,omitempty"`
+	WeakSecret string                 `json:"weakSecret,omitempty"`
+	Issues     []string               `json:"issues"`
+	Sources    []string               `json:"sources,omitempty"`
+	Error      string                 `json:"error,omitempty"`
+}
+
+// hmacHash returns the hash function of an HMAC JWT algorithm.
+func hmacHash(alg string) func() hash.Hash {
+	switch strings.ToUpper(alg) {
+	case "HS256":
+		return sha256.New
+	case "HS384":
+		return sha512.New384
+	case "HS512":
+		return sha512.New
+	}
+	return nil
+}
+
+// crackJWTSecret returns the weak secret that signed an HMAC token, if any.
+func crackJWTSecret(token jwtToken) (string, bool) {
+	newHash := hmacHash(token.alg())
+	i := strings.LastIndex(token.Raw, ".")
+	if newHash == nil || i < 0 {
+		return "", false
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(token.Signature, "="))
+	if err != nil {
+		return "", false
+	}
+	for _, secret := range weakJWTSecrets {
+		mac := hmac.New(newHash, []byte(secret))
+		mac.Write([]byte(token.Raw[:i]))
+		if hmac.Equal(mac.Sum(nil), signature) {
+			return secret, true
+		}
+	}
+	return "", false
+}
+
+// inspectJWT decodes a token and reports unsigned or weakly signed tokens,
+// missing or long expiry and sensitive claims. Nothing is sent anywhere.
+func inspectJWT(raw string, now time.Time) jwtReport {
+	report := jwtReport{Token: raw, Issues: []string{}}
+	token, err := decodeJWT(raw)
+	if err != nil {
+		report.Error = err.Error()
+		return report
+	}
+	report.Alg, report.Header, report.Claims = token.alg(), token.Header, token.Claims
+
+	switch {
+	case report.Alg == "" || strings.EqualFold(report.Alg, "none"):
+		report.Issues = append(report.Issues, "alg none: token is not signed")
+	case hmacHash(report.Alg) != nil:
+		if secret, ok := crackJWTSecret(token); ok {
+			report.WeakSecret = secret
+			report.Issues = append(report.Issues, fmt.Sprintf("signed with weak secret %q", secret))
+		} else {
+			report.Issues = append(report.Issues, "HMAC signed: the shared secret may be in client code")
+		}
+	}
+
+	iat, hasIat := token.timeClaim("iat")
+	if hasIat {
+		report.IssuedAt = iat.Format(time.RFC3339)
+	}
+	if exp, ok := token.timeClaim("exp"); ok {
+		report.ExpiresAt = exp.Format(time.RFC3339)
+		switch {
+		case exp.Before(now):
+			report.Issues = append(report.Issues, "expired")
+		case hasIat && exp.Sub(iat) > jwtMaxLifetime:
+			report.Issues = append(report.Issues, fmt.Sprintf("long expiry: valid for %d days", int(exp.Sub(iat).Hours()/24)))
+		case !hasIat && exp.Sub(now) > jwtMaxLifetime:
+			report.Issues = append(report.Issues, fmt.Sprintf("long expiry: valid for %d more days", int(exp.Sub(now).Hours()/24)))
+		}
+	} else {
+		report.Issues = append(report.Issues, "no expiry")
+	}
+
+	var sensitive []string
+	for claim := range token.Claims {
+		if sensitiveClaimPattern.MatchString(claim) {
+			sensitive = append(sensitive, claim)
+		}
+	}
+	if len(sensitive) > 0 {
+		sort.Strings(sensitive)
+		report.Issues = append(report.Issues, "sensitive claims: "+strings.Join(sensitive, ", "))
+	}
+	return report
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// jwtTokensField is the intelligence field holding the JWTs of a JS file. It
+// is only used here, so it is not a query field and is read from a result's
+// Extra keys.
+const jwtTokensField = "jwtTokens"
+
+// resultJWTs returns the JWTs stored in an intelligence result.
+func resultJWTs(result IntelligenceResult) ([]string, error) {
+	raw, ok := result.Extra[jwtTokensField]
+	if !ok {
+		return nil, nil
+	}
+	var tokens flexList
+	if err := json.Unmarshal(raw, &tokens); err != nil {
+		return nil, fmt.Errorf("error parsing %s of %s: %v", jwtTokensField, result.URL, err)
+	}
+	return tokens.Strings(), nil
+}
+
+// fetchJWTSources returns the JWTs found in the JS files of the given domains,
+// each with the URLs of the files that contain it.
+func fetchJWTSources(domains []string, size int, wkspId string) (map[string]map[string]bool, error) {
+	sources := map[string]map[string]bool{}
+	for _, domain := range domains {
+		results, err := fetchIntelligenceResults("domain", domain, []string{jwtTokensField}, size, wkspId)
+		if err != nil {
+			return nil, fmt.Errorf("error fetching results for %s: %v", domain, err)
+		}
+		for _, result := range results {
+			tokens, err := resultJWTs(result)
+			if err != nil {
+				return nil, err
+			}
+			for _, token := range tokens {
+				if token = strings.TrimSpace(token); token == "" {
+					continue
+				}
+				if sources[token] == nil {
+					sources[token] = map[string]bool{}
+				}
+				if result.URL != "" {
+					sources[token][result.URL] = true
+				}
+			}
+		}
+	}
+	return sources, nil
+}
+
+func sortedTokens(sources map[string]map[string]bool) []string {
+	tokens := make([]string, 0, len(sources))
+	for token := range sources {
+		tokens = append(tokens, token)
+	}
+	sort.Strings(tokens)
+	return tokens
+}
+
+func shortToken(token string) string {
+	if len(token) <= 40 {
+		return token
+	}
+	return token[:37] + "..."
+}
+
+func printJWTReports(reports []jwtReport) error {
+	if len(reports) == 0 {
+		fmt.Println("No JWTs found")
+		return nil
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "TOKEN\tALG\tEXPIRES\tISSUES\tSOURCE")
+	for _, report := range reports {
+		expires, issues, source := report.ExpiresAt, strings.Join(report.Issues, "; "), "-"
+		if expires == "" {
+			expires = "-"
+		}
+		if report.Error != "" {
+			issues = "cannot decode: " + report.Error
+		}
+		if len(report.Sources) > 0 {
+			source = report.Sources[0]
+			if len(report.Sources) > 1 {
+				source += fmt.Sprintf(" (+%d)", len(report.Sources)-1)
+			}
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", shortToken(report.Token), report.Alg, expires, issues, source)
+	}
+	return w.Flush()
+}
+
+func runJWTCommand(args []string) error {
+	fs := newCommandFlagSet("jwt", "jwt [token...] [-wksp <id>] [-d example.com] [-json]")
+	wkspId := fs.String("wksp", *workspaceFlag, "Workspace ID")
+	domains := fs.String("d", "", "Comma-separated domains to fetch JWTs from (default: every domain in the workspace)")
+	size := fs.Int("s", 100, "Number of JS files to fetch results for per domain")
+	jsonOutput := fs.Bool("json", false, "Print the decoded tokens as JSON")
+	positional, err := parseCommandArgs(fs, args)
+	if err != nil {
+		return err
+	}
+
+	// Tokens given as arguments are inspected without calling the API.
+	sources := map[string]map[string]bool{}
+	for _, token := range positional {
+		sources[strings.TrimSpace(token)] = nil
+	}
+	if len(positional) == 0 {
+		if err := ensureAPIKey(); err != nil {
+			return err
+		}
+		if err := requireWorkspace(*wkspId); err != nil {
+			return err
+		}
+		domainList := splitWords(*domains)
+		if len(domainList) == 0 {
+			if domainList, err = fetchDomains(*wkspId); err != nil {
+				return fmt.Errorf("error fetching domains: %v", err)
+			}
+		}
+		if sources, err = fetchJWTSources(domainList, *size, *wkspId); err != nil {
+			return err
+		}
+	}
+
+	now := time.Now()
+	reports := make([]jwtReport, 0, len(sources))
+	for _, token := range sortedTokens(sources) {
+		report := inspectJWT(token, now)
+		report.Sources = sortedKeys(sources[token])
+		reports = append(reports, report)
+	}
+
+	if *jsonOutput {
+		jsonData, err := json.MarshalIndent(reports, "", "  ")
+		if err != nil {
+			return fmt.Errorf("error formatting JSON: %v", err)
+		}
+		fmt.Println(string(jsonData))
+		return nil
+	}
+	return printJWTReports(reports)
+}
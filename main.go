@@ -8,7 +8,6 @@ import (
 	"net/http"
 	"os"
 	"os/exec"
-	"regexp"
 	"strings"
 )
 
@@ -31,6 +30,16 @@ var (
 	workspaceLong            *string
 	viewurls                 *bool
 	scanDomainFlag           *string
+	scanDomainListFlag       *string
+	concurrencyFlag          *int
+	expandKeywordsFlag       *bool
+	dryRunFlag               *bool
+	explainQuery             *bool
+	rawQuery                 *bool
+	pageFlag                 *int
+	startFlag                *int
+	allPagesFlag             *bool
+	jsonlFlag                *bool
 	wordsFlag                *string
 	urlswithmultipleResponse *bool
 	getDomainsFlag           *bool
@@ -43,6 +52,7 @@ var (
 	searchUrlsByDomainFlag   *string
 	getResultByJsmonId       *string
 	getResultByFileId        *string
+	showFlag                 *string
 	totalAnalysisDataFlag    *bool
 )
 
@@ -171,6 +181,16 @@ func init() {
 	workspaceLong = flag.String("createWorkspace", "", "Create a new workspace (Example: -createWorkspace nandini)")
 	viewurls = flag.Bool("urls", false, "view all urls")
 	scanDomainFlag = flag.String("d", "", "Domain to automate scan")
+	scanDomainListFlag = flag.String("dL", "", "File with domains to automate scan, one per line (Example: example.co.uk words=example,exmpl)")
+	concurrencyFlag = flag.Int("c", 5, "Number of domains to scan concurrently with -dL (default 5)")
+	expandKeywordsFlag = flag.Bool("expand", false, "Expand scan words with hyphen splits, brand variations and subdomain labels")
+	dryRunFlag = flag.Bool("dry-run", false, "Show the domains and words a scan would use without scanning")
+	pageFlag = flag.Int("page", 0, "Page of -query results to fetch")
+	startFlag = flag.Int("start", 0, "Offset of the first URL to fetch with -urls")
+	allPagesFlag = flag.Bool("all", false, "Fetch every page of -query or -urls results until none are left")
+	jsonlFlag = flag.Bool("jsonl", false, "Print -query results as one JSON object per line")
+	explainQuery = flag.Bool("explain", false, "Validate -query and print it in the server's query format without running it")
+	rawQuery = flag.Bool("raw", false, "Send -query to the server verbatim, without validating or translating it")
 	wordsFlag = flag.String("w", "", "Comma-separated list of words to include in the scan")
 	urlswithmultipleResponse = flag.Bool("curls", false, "View changed JS URLs.")
 	getDomainsFlag = flag.Bool("domains", false, "Get all domains for the user.")
@@ -183,12 +203,18 @@ func init() {
 	searchUrlsByDomainFlag = flag.String("urlsByDomain", "", "Search URLs by domain")
 	getResultByJsmonId = flag.String("jsiJsmonId", "", "Get JS Intelligence for the jsmon ID.")
 	getResultByFileId = flag.String("jsiFileId", "", "Get JS Intelligence for the file ID.")
+	showFlag = flag.String("show", "", "Comma-separated intelligence fields to show with -jsi, -jsiJsmonId and -jsiFileId (e.g. apis,emails)")
 	totalAnalysisDataFlag = flag.Bool("count", false, "total count of overall analysis data")
+}
 
-	flag.Parse()
+// isDryRunScan reports whether the command line only previews a domain scan,
+// which needs no API key.
+func isDryRunScan() bool {
+	return flag.NArg() == 0 && *dryRunFlag && (*scanDomainListFlag != "" || *scanDomainFlag != "")
 }
 
 func main() {
+	flag.Parse()
 	if !silentFlag {
 		showBanner()
 		displayVersion()
@@ -203,7 +229,7 @@ func main() {
 	}
 	if *apiKeyFlag != "" {
 		setAPIKey(*apiKeyFlag)
-	} else {
+	} else if !offlineCommands[flag.Arg(0)] && !isDryRunScan() {
 		err := loadAPIKey()
 		if err != nil {
 			fmt.Println("Error loading API key:", err)
@@ -212,11 +238,23 @@ func main() {
 		}
 	}
 
+	if flag.NArg() > 0 {
+		if err := runSubcommand(flag.Arg(0), flag.Args()[1:]); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	if flag.NFlag() == 0 || (flag.NFlag() == 1 && *apiKeyFlag != "") {
 		fmt.Println("No action specified. Use -h or --help for usage information.")
 		flag.Usage()
 		os.Exit(1)
 	}
+	if *startFlag != 0 && !*viewurls {
+		fmt.Println("Error: -start only works with -urls, use -page to pick a page of -query results")
+		os.Exit(1)
+	}
 
 	if *listWorkspacesFlag {
 		err := displayWorkspaces()
@@ -239,7 +277,10 @@ func main() {
 			}
 			os.Exit(1)
 		}
-		uploadFileEndpoint(*uploadFile, headers, *workspaceFlag)
+		if err := uploadFileEndpoint(*uploadFile, headers, *workspaceFlag); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
 	case *workspaceShort != "":
 		createWorkspace(*workspaceShort)
 	case *workspaceLong != "":
@@ -254,7 +295,7 @@ func main() {
 			os.Exit(1)
 		}
 
-		err := viewUrls(*size, *workspaceFlag)
+		err := viewUrls(*size, *startFlag, *allPagesFlag, *workspaceFlag)
 		if err != nil {
 			fmt.Printf("Error: %v\n", err)
 			os.Exit(1)
@@ -314,6 +355,19 @@ func main() {
 		}
 		urlsmultipleResponse(*workspaceFlag)
 	case *query != "":
+		if *explainQuery && *rawQuery {
+			fmt.Println(strings.TrimSpace(*query))
+			return
+		}
+		if *explainQuery {
+			compiled, err := compileQuery(*query)
+			if err != nil {
+				fmt.Printf("Error: invalid query: %v\n", err)
+				os.Exit(1)
+			}
+			fmt.Println(compiled)
+			return
+		}
 		if *workspaceFlag == "" {
 			fmt.Println("No workspace specified. Use -workspaces to list available workspaces and provide a workspace ID using the -wksp flag.")
 			err := displayWorkspaces()
@@ -322,8 +376,11 @@ func main() {
 			}
 			os.Exit(1)
 		}
-		// constructedQuery := fmt.Sprintf("field = %s, sub = %v, domain = %s", *field, *sub, *domain)
-		queryBuilder(*workspaceFlag, *query)
+		options := queryOptions{Page: *pageFlag, All: *allPagesFlag, JSONL: *jsonlFlag, Raw: *rawQuery}
+		if err := queryBuilder(*workspaceFlag, *query, options); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
 	case *getResultByJsmonId != "":
 		if *workspaceFlag == "" {
 			fmt.Println("No workspace specified. Use -workspaces to list available workspaces and provide a workspace ID using the -wksp flag.")
@@ -333,7 +390,15 @@ func main() {
 			}
 			os.Exit(1)
 		}
-		getAutomationResultsByJsmonId(strings.TrimSpace(*getResultByJsmonId), *(workspaceFlag))
+		show, err := parseShowFields(*showFlag)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		if err := getAutomationResultsByJsmonId(strings.TrimSpace(*getResultByJsmonId), *workspaceFlag, show); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
 	case *reverseSearchResults != "":
 		parts := strings.SplitN(*reverseSearchResults, "=", 2)
 		if len(parts) != 2 {
@@ -351,7 +416,10 @@ func main() {
 			}
 			os.Exit(1)
 		}
-		getAutomationResultsByInput(field, value, *workspaceFlag)
+		if err := getAutomationResultsByInput(field, value, *workspaceFlag); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
 
 	case *getResultByFileId != "":
 		if *workspaceFlag == "" {
@@ -362,7 +430,15 @@ func main() {
 			}
 			os.Exit(1)
 		}
-		getAutomationResultsByFileId(strings.TrimSpace(*getResultByFileId), *workspaceFlag)
+		show, err := parseShowFields(*showFlag)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		if err := getAutomationResultsByFileId(strings.TrimSpace(*getResultByFileId), *workspaceFlag, show); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
 
 	case *getScannerResultsFlag:
 		if *workspaceFlag == "" {
@@ -373,7 +449,10 @@ func main() {
 			}
 			os.Exit(1)
 		}
-		getScannerResults(*workspaceFlag)
+		if err := getScannerResults(*workspaceFlag); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
 	case *getDomainsFlag:
 		if *workspaceFlag == "" {
 			fmt.Println("No workspace specified. Use -workspaces to list available workspaces and provide a workspace ID using the -wksp flag.")
@@ -383,7 +462,10 @@ func main() {
 			}
 			os.Exit(1)
 		}
-		getDomains(*workspaceFlag)
+		if err := getDomains(*workspaceFlag); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
 	case *getAllResults != "":
 		if *workspaceFlag == "" {
 			fmt.Println("No workspace specified. Use -workspaces to list available workspaces and provide a workspace ID using the -wksp flag.")
@@ -394,20 +476,48 @@ func main() {
 			os.Exit(1)
 		}
 
-		err := getAllAutomationResults(*getAllResults, *size, *workspaceFlag)
+		show, err := parseShowFields(*showFlag)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		err = getAllAutomationResults(*getAllResults, *size, *workspaceFlag, show)
 		if err != nil {
 			fmt.Printf("Error: %v\n", err)
 			os.Exit(1)
 		}
+	case *scanDomainListFlag != "":
+		targets, err := parseDomainList(*scanDomainListFlag, splitWords(*wordsFlag), *expandKeywordsFlag)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		if *dryRunFlag {
+			printScanPlan(targets)
+			return
+		}
+		if *workspaceFlag == "" {
+			fmt.Println("No workspace specified. Use -workspaces to list available workspaces and provide a workspace ID using the -wksp flag.")
+			err := displayWorkspaces()
+			if err != nil {
+				fmt.Printf("Error listing workspaces: %v\n", err)
+			}
+			os.Exit(1)
+		}
+		if err := scanDomainList(targets, *workspaceFlag, *concurrencyFlag); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
 	case *scanDomainFlag != "":
 		words := []string{}
 		if *wordsFlag != "" {
-			words = strings.Split(*wordsFlag, ",")
+			words = splitWords(*wordsFlag)
 		} else {
-			rootWord := extractRootWord(*scanDomainFlag)
-			if rootWord != "" {
-				words = []string{rootWord}
-			}
+			words = scanWords(*scanDomainFlag, *expandKeywordsFlag)
+		}
+		if *dryRunFlag {
+			printScanPlan([]domainScanTarget{{Domain: *scanDomainFlag, Words: words}})
+			return
 		}
 		if *workspaceFlag == "" {
 			fmt.Println("No workspace specified. Use -workspaces to list available workspaces and provide a workspace ID using the -wksp flag.")
@@ -460,32 +570,3 @@ func main() {
 		os.Exit(1)
 	}
 }
-
-func extractRootWord(domain string) string {
-	// Remove common TLDs and subdomains
-	domain = strings.TrimSpace(domain)
-	domain = strings.ToLower(domain)
-
-	// Remove protocol if present
-	if strings.Contains(domain, "://") {
-		parts := strings.Split(domain, "://")
-		if len(parts) > 1 {
-			domain = parts[1]
-		}
-	}
-
-	// Split by dots and get the main domain part
-	parts := strings.Split(domain, ".")
-	if len(parts) < 2 {
-		return domain
-	}
-
-	// Get the part before the TLD
-	mainPart := parts[len(parts)-2]
-
-	// Remove any non-alphanumeric characters
-	reg := regexp.MustCompile("[^a-zA-Z0-9]+")
-	mainPart = reg.ReplaceAllString(mainPart, "")
-
-	return mainPart
-}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	_ "embed"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+)
+
+// public_suffix_list.dat is the unmodified upstream list from
+// https://publicsuffix.org/list/public_suffix_list.dat, both the ICANN and
+// the private sections, as of 2023-02-09 (publicsuffix 20230209.2326). Replace
+// the whole file to refresh it.
+//
+//go:embed public_suffix_list.dat
+var publicSuffixListData string
+
+type publicSuffixRules struct {
+	exact      map[string]bool
+	wildcards  map[string]bool
+	exceptions map[string]bool
+}
+
+var (
+	suffixRules     publicSuffixRules
+	suffixRulesOnce sync.Once
+)
+
+func loadPublicSuffixRules() publicSuffixRules {
+	suffixRulesOnce.Do(func() {
+		suffixRules = publicSuffixRules{
+			exact:      map[string]bool{},
+			wildcards:  map[string]bool{},
+			exceptions: map[string]bool{},
+		}
+		for _, line := range strings.Split(publicSuffixListData, "\n") {
+			rule := strings.TrimSpace(line)
+			if rule == "" || strings.HasPrefix(rule, "//") {
+				continue
+			}
+			rule = strings.ToLower(strings.Fields(rule)[0])
+			switch {
+			case strings.HasPrefix(rule, "!"):
+				suffixRules.exceptions[strings.TrimPrefix(rule, "!")] = true
+			case strings.HasPrefix(rule, "*."):
+				suffixRules.wildcards[strings.TrimPrefix(rule, "*.")] = true
+			default:
+				suffixRules.exact[rule] = true
+			}
+		}
+	})
+	return suffixRules
+}
+
+// normalizeHost strips the scheme, credentials, port, path and trailing dot from
+// a domain or URL and lowercases it.
+func normalizeHost(input string) string {
+	host := strings.ToLower(strings.TrimSpace(input))
+	if i := strings.Index(host, "://"); i >= 0 {
+		host = host[i+3:]
+	}
+	if i := strings.IndexAny(host, "/?#"); i >= 0 {
+		host = host[:i]
+	}
+	if i := strings.LastIndex(host, "@"); i >= 0 {
+		host = host[i+1:]
+	}
+	if strings.HasPrefix(host, "[") {
+		if i := strings.Index(host, "]"); i >= 0 {
+			return host[1:i]
+		}
+	}
+	if net.ParseIP(host) != nil {
+		return host
+	}
+	if i := strings.LastIndex(host, ":"); i >= 0 {
+		host = host[:i]
+	}
+	host = strings.TrimPrefix(host, "*.")
+	return strings.Trim(host, ".")
+}
+
+// publicSuffix returns the public suffix of host using the embedded list.
+// Hosts under an unlisted TLD fall back to the implicit "*" rule.
+func publicSuffix(host string) string {
+	rules := loadPublicSuffixRules()
+	labels := strings.Split(host, ".")
+	for i := range labels {
+		suffix := strings.Join(labels[i:], ".")
+		if rules.exceptions[suffix] {
+			return strings.Join(labels[i+1:], ".")
+		}
+		if rules.exact[suffix] {
+			return suffix
+		}
+		if i+1 < len(labels) && rules.wildcards[strings.Join(labels[i+1:], ".")] {
+			return suffix
+		}
+	}
+	return labels[len(labels)-1]
+}
+
+// registrableDomain returns the public suffix plus one label, e.g.
+// "shop.example.co.uk" -> "example.co.uk".
+func registrableDomain(domain string) (string, error) {
+	host := normalizeHost(domain)
+	if host == "" {
+		return "", fmt.Errorf("empty domain")
+	}
+	if net.ParseIP(host) != nil {
+		return "", fmt.Errorf("%s is an IP address", host)
+	}
+	suffix := publicSuffix(host)
+	if host == suffix {
+		return "", fmt.Errorf("%s is a public suffix", host)
+	}
+	rest := strings.TrimSuffix(host, "."+suffix)
+	labels := strings.Split(rest, ".")
+	return labels[len(labels)-1] + "." + suffix, nil
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRegistrableDomain(t *testing.T) {
+	tests := []struct {
+		domain string
+		want   string
+	}{
+		{"example.com", "example.com"},
+		{"https://user:pw@www.Example.COM:8443/app.js?x=1", "example.com"},
+		{"shop.example.co.uk", "example.co.uk"},
+		{"a.b.example.com.au", "example.com.au"},
+		{"*.example.org.", "example.org"},
+		// *.ck makes every second-level label a suffix, except !www.ck.
+		{"shop.brand.gov.ck", "brand.gov.ck"},
+		{"www.ck", "www.ck"},
+		{"a.www.ck", "www.ck"},
+		// *.kawasaki.jp with the !city.kawasaki.jp exception.
+		{"foo.bar.kawasaki.jp", "foo.bar.kawasaki.jp"},
+		{"www.city.kawasaki.jp", "city.kawasaki.jp"},
+		// Private suffixes.
+		{"user.github.io", "user.github.io"},
+		{"assets.my-app.vercel.app", "my-app.vercel.app"},
+		{"bucket.s3.amazonaws.com", "bucket.s3.amazonaws.com"},
+		// Unlisted TLDs fall back to the implicit * rule.
+		{"service.internal-tld", "service.internal-tld"},
+	}
+	for _, tt := range tests {
+		got, err := registrableDomain(tt.domain)
+		if err != nil || got != tt.want {
+			t.Errorf("registrableDomain(%q) = %q, %v, want %q", tt.domain, got, err, tt.want)
+		}
+	}
+}
+
+func TestRegistrableDomainErrors(t *testing.T) {
+	tests := []struct {
+		domain  string
+		wantErr string
+	}{
+		{"", "empty domain"},
+		{"co.uk", "public suffix"},
+		{"github.io", "public suffix"},
+		{"x.ck", "public suffix"},
+		{"192.168.1.10", "IP address"},
+		{"http://10.0.0.1:8080/app.js", "IP address"},
+		{"[2001:db8::1]:443", "IP address"},
+		{"2001:db8::1", "IP address"},
+	}
+	for _, tt := range tests {
+		got, err := registrableDomain(tt.domain)
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("registrableDomain(%q) = %q, %v, want error containing %q", tt.domain, got, err, tt.wantErr)
+		}
+	}
+}
+
+func TestExtractRootWord(t *testing.T) {
+	tests := []struct {
+		domain string
+		want   string
+	}{
+		{"shop.example.co.uk", "example"},
+		{"https://cdn.my-brand.com/app.js", "mybrand"},
+		{"user.github.io", "user"},
+		{"www.city.kawasaki.jp", "city"},
+		{"192.168.1.10", ""},
+		{"[::1]:8080", ""},
+	}
+	for _, tt := range tests {
+		if got := extractRootWord(tt.domain); got != tt.want {
+			t.Errorf("extractRootWord(%q) = %q, want %q", tt.domain, got, tt.want)
+		}
+	}
+}
+
+func TestExpandKeywords(t *testing.T) {
+	tests := []struct {
+		domain string
+		want   []string
+	}{
+		{"shop.my-brand24.co.uk", []string{"mybrand24", "my-brand24", "brand24", "my-brand", "mybrand"}},
+		{"payments.api.example.com", []string{"example", "payments"}},
+		{"10.0.0.1", []string{}},
+	}
+	for _, tt := range tests {
+		if got := expandKeywords(tt.domain); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("expandKeywords(%q) = %q, want %q", tt.domain, got, tt.want)
+		}
+	}
+}
@@ -6,6 +6,9 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"os"
+	"strconv"
 	"strings"
 )
 
@@ -34,36 +37,73 @@ var fieldMapping = map[string]string{
 	"jsUrls":                 "jsUrls",
 }
 
+// queryField documents a field name accepted by field: in queries.
+type queryField struct {
+	Description string
+	Example     string
+}
+
+var fieldDescriptions = map[string]queryField{
+	"urls":                   {"URLs referenced in JS files", "https://api.example.com/v1/users"},
+	"domains":                {"Domains and subdomains referenced in JS files", "cdn.example.com"},
+	"ipv4":                   {"IPv4 addresses", "10.0.12.4"},
+	"ipv6":                   {"IPv6 addresses", "2001:db8::1"},
+	"emails":                 {"Email addresses", "support@example.com"},
+	"cloud-buckets":          {"Cloud storage buckets (S3, GCS, Azure)", "assets-prod.s3.amazonaws.com"},
+	"apis":                   {"API paths and endpoints", "/api/v2/users/{id}"},
+	"gql-queries":            {"GraphQL queries", "query GetUser { user { id } }"},
+	"gql-mutations":          {"GraphQL mutations", "mutation UpdateUser { ... }"},
+	"node-modules-confusion": {"npm package names not found on the public registry (dependency confusion candidates)", "@example/internal-utils"},
+	"node-modules":           {"npm packages that exist on the public registry", "lodash"},
+	"gql-fragments":          {"GraphQL fragments", "fragment UserFields on User { ... }"},
+	"vulnerabilities":        {"Vulnerable libraries and patterns detected in JS files", "jquery 1.12.4"},
+	"guids":                  {"GUIDs and UUIDs", "3f2504e0-4f89-11d3-9a0c-0305e82c3301"},
+	"domains-status":         {"Extracted domains with their resolution status", "old.example.com (NXDOMAIN)"},
+	"urls-parameters":        {"URLs with query parameters", "https://example.com/search?q=test"},
+	"bucket-takeovers":       {"Referenced cloud buckets that do not exist and may be claimable", "old-assets.s3.amazonaws.com"},
+	"urls-socialmedia":       {"Social media profile URLs", "https://twitter.com/example"},
+	"urls-localhost":         {"URLs pointing at localhost or private hosts", "http://localhost:8080/debug"},
+	"urls-ports":             {"URLs with a non-standard port", "https://example.com:8443/admin"},
+	"exposures":              {"Exposed configuration and debug information", "sentry DSN"},
+	"jsUrls":                 {"JS file URLs", "https://example.com/static/main.js"},
+}
+
 type QueryBuilderResponse struct {
 	PaginatedResults         []map[string]interface{} `json:"paginatedResults"`
 	URLs                     []string                 `json:"urls"`
 	IsReverseSearchAvailable bool                     `json:"isReverseSearchAvailable"`
 }
 
-func queryBuilder(wkspId, query string) {
+// queryOptions control which result pages queryBuilder fetches and how the
+// results are printed.
+type queryOptions struct {
+	Page  int
+	All   bool
+	JSONL bool
+	// Raw sends the query verbatim instead of validating and translating it.
+	Raw bool
+}
 
-	if strings.HasPrefix(query, "field=") {
-		fieldType := strings.TrimPrefix(query, "field=")
-		if mappedField, exists := fieldMapping[fieldType]; exists {
-			query = fmt.Sprintf("field:%s", mappedField)
-		} else {
-			fmt.Printf("Field type '%s' not found in mapping\n", fieldType)
-		}
+// fetchQueryPage runs query and returns one page of its results. The page is
+// sent as a request parameter, not as part of the query; 0 leaves it to the
+// server.
+func fetchQueryPage(wkspId, query string, page int) (QueryBuilderResponse, error) {
+	params := url.Values{}
+	params.Set("wkspId", wkspId)
+	if page > 0 {
+		params.Set("page", strconv.Itoa(page))
 	}
-
-	endpoint := fmt.Sprintf("%s/queryBuilder?wkspId=%s", apiBaseURL, wkspId)
+	endpoint := fmt.Sprintf("%s/queryBuilder?%s", apiBaseURL, params.Encode())
 
 	requestBody, err := json.Marshal(map[string]string{
 		"query": query,
 	})
 	if err != nil {
-		fmt.Printf("Failed to marshal request body: %v\n", err)
-		return
+		return QueryBuilderResponse{}, fmt.Errorf("failed to marshal request body: %v", err)
 	}
 	req, err := http.NewRequest("POST", endpoint, bytes.NewBuffer(requestBody))
 	if err != nil {
-		fmt.Printf("Failed to create request: %v\n", err)
-		return
+		return QueryBuilderResponse{}, fmt.Errorf("failed to create request: %v", err)
 	}
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("X-Jsmon-Key", strings.TrimSpace(apiKey))
@@ -71,44 +111,109 @@ func queryBuilder(wkspId, query string) {
 	client := &http.Client{}
 	resp, err := client.Do(req)
 	if err != nil {
-		fmt.Printf("Failed to send request: %v\n", err)
-		return
+		return QueryBuilderResponse{}, fmt.Errorf("failed to send request: %v", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode == http.StatusUnauthorized {
-		fmt.Println("[ERR] Wrong API key")
-		return
+		return QueryBuilderResponse{}, fmt.Errorf("wrong API key")
 	}
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		fmt.Printf("Failed to read response body: %v\n", err)
-		return
+		return QueryBuilderResponse{}, fmt.Errorf("failed to read response body: %v", err)
 	}
 
 	var result QueryBuilderResponse
 	if err := json.Unmarshal(body, &result); err != nil {
-		fmt.Printf("Failed to parse JSON: %v\n", err)
-		return
+		return QueryBuilderResponse{}, fmt.Errorf("failed to parse JSON: %v", err)
+	}
+	return result, nil
+}
+
+// printQueryResults prints one page of results and returns how many it
+// printed. With jsonl each result is a single line of JSON.
+func printQueryResults(result QueryBuilderResponse, jsonl bool) int {
+	for _, url := range result.URLs {
+		fmt.Println(url)
 	}
 	if len(result.URLs) > 0 {
-		for _, url := range result.URLs {
-			fmt.Println(url)
+		return len(result.URLs)
+	}
+	for _, item := range result.PaginatedResults {
+		var jsonData []byte
+		var err error
+		if jsonl {
+			jsonData, err = json.Marshal(item)
+		} else {
+			jsonData, err = json.MarshalIndent(item, "", "    ")
+		}
+		if err != nil {
+			fmt.Printf("Error formatting result: %v\n", err)
+			continue
 		}
-		return
+		fmt.Println(string(jsonData))
 	}
-	if len(result.PaginatedResults) > 0 {
-		for _, item := range result.PaginatedResults {
-			jsonData, err := json.MarshalIndent(item, "", "    ")
-			if err != nil {
-				fmt.Printf("Error formatting result: %v\n", err)
-				continue
+	return len(result.PaginatedResults)
+}
+
+func queryBuilder(wkspId, query string, options queryOptions) error {
+	compiled, page := strings.TrimSpace(query), options.Page
+	if !options.Raw {
+		node, err := parseQuery(compiled)
+		if err != nil {
+			return fmt.Errorf("invalid query: %v", err)
+		}
+		node, page = splitQueryPage(node)
+		if options.Page > 0 {
+			if page > 0 && page != options.Page {
+				return fmt.Errorf("the query asks for page %d but -page is %d", page, options.Page)
 			}
-			fmt.Println(string(jsonData))
+			page = options.Page
+		}
+		compiled = ""
+		if node != nil {
+			compiled = translateQuery(node)
+		}
+	}
+
+	if !options.All {
+		result, err := fetchQueryPage(wkspId, compiled, page)
+		if err != nil {
+			return err
 		}
-		return
+		if printQueryResults(result, options.JSONL) == 0 {
+			fmt.Println("No results found")
+		}
+		return nil
 	}
 
-	fmt.Println("No results found")
+	// Follow the pages until one comes back empty. Results are printed as
+	// each page arrives; a page identical to the previous one means the
+	// server ignored the page number, so stop instead of looping forever.
+	if page == 0 {
+		page = 1
+	}
+	total := 0
+	previous := ""
+	for ; ; page++ {
+		result, err := fetchQueryPage(wkspId, compiled, page)
+		if err != nil {
+			return fmt.Errorf("error fetching page %d: %v", page, err)
+		}
+		if len(result.URLs) == 0 && len(result.PaginatedResults) == 0 {
+			break
+		}
+		fingerprint, _ := json.Marshal(result)
+		if string(fingerprint) == previous {
+			fmt.Fprintf(os.Stderr, "[WRN] Page %d repeats page %d, stopping\n", page, page-1)
+			break
+		}
+		previous = string(fingerprint)
+		total += printQueryResults(result, options.JSONL)
+	}
+	if total == 0 {
+		fmt.Println("No results found")
+	}
+	return nil
 }
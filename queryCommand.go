@@ -0,0 +1,279 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// queryParams collects repeated -p key=value flags.
+type queryParams map[string]string
+
+func (p queryParams) String() string {
+	var pairs []string
+	for name, value := range p {
+		pairs = append(pairs, name+"="+value)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+func (p queryParams) Set(value string) error {
+	i := strings.Index(value, "=")
+	if i <= 0 {
+		return fmt.Errorf("expected key=value, got %q", value)
+	}
+	p[strings.TrimSpace(value[:i])] = strings.TrimSpace(value[i+1:])
+	return nil
+}
+
+func runQueryCommand(args []string) error {
+	usage := "query save|run|list|delete|fields [flags]"
+	if len(args) == 0 {
+		return fmt.Errorf("usage: jsmon %s", usage)
+	}
+
+	switch args[0] {
+	case "save":
+		return runQuerySave(args[1:])
+	case "run":
+		return runSavedQuery(args[1:])
+	case "list":
+		return runQueryList(args[1:])
+	case "delete":
+		return runQueryDelete(args[1:])
+	case "fields":
+		return runQueryFields(args[1:])
+	case "-h", "-help", "--help":
+		fmt.Printf("Usage: jsmon %s\n", usage)
+		return nil
+	}
+	return fmt.Errorf("unknown query command %q, use save, run, list, delete or fields", args[0])
+}
+
+func runQuerySave(args []string) error {
+	fs := newCommandFlagSet("query save", "query save <name> \"<query>\" [-desc text] [-force]")
+	description := fs.String("desc", "", "Description shown by query list")
+	force := fs.Bool("force", false, "Overwrite an existing saved query")
+	positional, err := parseCommandArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 2 {
+		fs.Usage()
+		return fmt.Errorf("query save needs a name and a query")
+	}
+	name, query := positional[0], strings.TrimSpace(positional[1])
+	if !queryNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid name %q, use letters, digits, '.', '_' and '-'", name)
+	}
+	if err := validateSavedQuery(query); err != nil {
+		return fmt.Errorf("invalid query: %v", err)
+	}
+
+	queries, err := loadSavedQueries()
+	if err != nil {
+		return err
+	}
+	if _, exists := queries[name]; exists && !*force {
+		return fmt.Errorf("a query named %q already exists, use -force to overwrite it", name)
+	}
+	queries[name] = savedQuery{Query: query, Description: *description}
+	if err := writeSavedQueries(queries); err != nil {
+		return err
+	}
+	fmt.Printf("[INF] Saved query %q\n", name)
+	return nil
+}
+
+func runSavedQuery(args []string) error {
+	fs := newCommandFlagSet("query run", "query run <name> [-p key=value]... [-domain example.com] [flags]")
+	wkspId := fs.String("wksp", *workspaceFlag, "Workspace ID")
+	params := queryParams{}
+	fs.Var(params, "p", "Placeholder value as key=value (can be used multiple times)")
+	domain := fs.String("domain", "", "Shorthand for -p domain=<value>")
+	page := fs.Int("page", 0, "Page of results to fetch")
+	all := fs.Bool("all", false, "Fetch every page of results")
+	jsonl := fs.Bool("jsonl", false, "Print results as one JSON object per line")
+	explain := fs.Bool("explain", false, "Print the query in the server's query format without running it")
+	positional, err := parseCommandArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		fs.Usage()
+		return fmt.Errorf("query run needs the name of a saved query")
+	}
+	if *domain != "" {
+		params["domain"] = *domain
+	}
+
+	saved, err := findSavedQuery(positional[0])
+	if err != nil {
+		return err
+	}
+	query, err := fillPlaceholders(saved.Query, params)
+	if err != nil {
+		return err
+	}
+	if *explain {
+		compiled, err := compileQuery(query)
+		if err != nil {
+			return fmt.Errorf("invalid query: %v", err)
+		}
+		fmt.Println(compiled)
+		return nil
+	}
+	if err := requireWorkspace(*wkspId); err != nil {
+		return err
+	}
+	return queryBuilder(*wkspId, query, queryOptions{Page: *page, All: *all, JSONL: *jsonl})
+}
+
+func runQueryList(args []string) error {
+	fs := newCommandFlagSet("query list", "query list [-json] [-builtin=false]")
+	jsonOutput := fs.Bool("json", false, "Print the queries as JSON")
+	builtin := fs.Bool("builtin", true, "Include the built-in queries")
+	positional, err := parseCommandArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) > 0 {
+		return fmt.Errorf("unexpected arguments: %s", strings.Join(positional, " "))
+	}
+
+	saved, err := loadSavedQueries()
+	if err != nil {
+		return err
+	}
+	type listedQuery struct {
+		Name   string `json:"name"`
+		Source string `json:"source"`
+		savedQuery
+	}
+	var listed []listedQuery
+	for _, name := range sortedQueryNames(saved) {
+		listed = append(listed, listedQuery{name, "saved", saved[name]})
+	}
+	if *builtin {
+		builtins := builtinQueries()
+		for _, name := range sortedQueryNames(builtins) {
+			if _, overridden := saved[name]; !overridden {
+				listed = append(listed, listedQuery{name, "builtin", builtins[name]})
+			}
+		}
+	}
+
+	if *jsonOutput {
+		if listed == nil {
+			listed = []listedQuery{}
+		}
+		jsonData, err := json.MarshalIndent(listed, "", "  ")
+		if err != nil {
+			return fmt.Errorf("error formatting JSON: %v", err)
+		}
+		fmt.Println(string(jsonData))
+		return nil
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tSOURCE\tQUERY\tDESCRIPTION")
+	for _, q := range listed {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", q.Name, q.Source, q.Query, q.Description)
+	}
+	return w.Flush()
+}
+
+func runQueryDelete(args []string) error {
+	fs := newCommandFlagSet("query delete", "query delete <name>")
+	positional, err := parseCommandArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		fs.Usage()
+		return fmt.Errorf("query delete needs the name of a saved query")
+	}
+	name := positional[0]
+	queries, err := loadSavedQueries()
+	if err != nil {
+		return err
+	}
+	if _, ok := queries[name]; !ok {
+		if _, builtin := builtinQueries()[name]; builtin {
+			return fmt.Errorf("%q is a built-in query and cannot be deleted", name)
+		}
+		return fmt.Errorf("no saved query named %q%s", name, didYouMean(name, sortedQueryNames(queries)))
+	}
+	delete(queries, name)
+	if err := writeSavedQueries(queries); err != nil {
+		return err
+	}
+	fmt.Printf("[INF] Deleted query %q\n", name)
+	return nil
+}
+
+func runQueryFields(args []string) error {
+	fs := newCommandFlagSet("query fields", "query fields [name] [-json]")
+	jsonOutput := fs.Bool("json", false, "Print the fields as JSON")
+	positional, err := parseCommandArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) > 1 {
+		return fmt.Errorf("unexpected arguments: %s", strings.Join(positional[1:], " "))
+	}
+
+	names := make([]string, 0, len(fieldMapping))
+	for name := range fieldMapping {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	if len(positional) == 1 {
+		backend, err := resolveQueryField(positional[0])
+		if err != nil {
+			return err
+		}
+		for _, name := range names {
+			if fieldMapping[name] == backend {
+				names = []string{name}
+				break
+			}
+		}
+	}
+
+	type listedField struct {
+		Name        string `json:"name"`
+		Backend     string `json:"backend"`
+		Description string `json:"description"`
+		Example     string `json:"example"`
+		Query       string `json:"query"`
+	}
+	fields := make([]listedField, len(names))
+	for i, name := range names {
+		fields[i] = listedField{
+			Name:        name,
+			Backend:     fieldMapping[name],
+			Description: fieldDescriptions[name].Description,
+			Example:     fieldDescriptions[name].Example,
+			Query:       "field:" + name,
+		}
+	}
+
+	if *jsonOutput {
+		jsonData, err := json.MarshalIndent(fields, "", "  ")
+		if err != nil {
+			return fmt.Errorf("error formatting JSON: %v", err)
+		}
+		fmt.Println(string(jsonData))
+		return nil
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "FIELD\tBACKEND\tDESCRIPTION\tEXAMPLE")
+	for _, f := range fields {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", f.Name, f.Backend, f.Description, f.Example)
+	}
+	return w.Flush()
+}
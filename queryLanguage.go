@@ -0,0 +1,456 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// The -query language:
+//
+//	query  = or
+//	or     = and { "OR" and }
+//	and    = unary { ["AND"] unary | "NOT" unary }
+//	unary  = "NOT" unary | "(" or ")" | term
+//	term   = key op value
+//	op     = ":" | "=" | "!=" | "~"
+//	value  = word | "quoted string"
+//
+// Terms next to each other are ANDed; "a NOT b" means "a AND NOT b". The
+// operators may also be written as &&, || and !. "~" matches a substring.
+//
+//	field:apis AND domain:example.com NOT value~"/internal"
+
+// queryKeys are the keys a term may use and the operators each accepts.
+var queryKeys = map[string][]string{
+	"field":  {":", "="},
+	"domain": {":", "=", "!=", "~"},
+	"value":  {":", "=", "!=", "~"},
+	"url":    {":", "=", "!=", "~"},
+	"sub":    {":", "="},
+	"page":   {":", "="},
+}
+
+// queryModifiers apply to the whole query and may only appear in a top-level
+// AND chain.
+var queryModifiers = map[string]bool{"sub": true, "page": true}
+
+type queryNode interface{}
+
+type queryTerm struct {
+	Key      string
+	Op       string
+	Value    string
+	Pos      int
+	OpPos    int
+	ValuePos int
+}
+
+type queryBinary struct {
+	Op          string
+	Left, Right queryNode
+}
+
+type queryNot struct {
+	Expr queryNode
+}
+
+// QueryError is a syntax or validation error at a position in the query.
+type QueryError struct {
+	Query string
+	Pos   int
+	Msg   string
+}
+
+func (e *QueryError) Error() string {
+	return fmt.Sprintf("%s\n  %s\n  %s^", e.Msg, e.Query, strings.Repeat(" ", e.Pos))
+}
+
+type queryTokenKind int
+
+const (
+	qtWord queryTokenKind = iota
+	qtString
+	qtOp
+	qtLParen
+	qtRParen
+	qtAnd
+	qtOr
+	qtNot
+	qtEOF
+)
+
+type queryToken struct {
+	Kind  queryTokenKind
+	Value string
+	Pos   int
+}
+
+func lexQuery(query string) ([]queryToken, error) {
+	var tokens []queryToken
+	afterOp := false
+	i := 0
+	for i < len(query) {
+		c := query[i]
+		start := i
+		switch {
+		case c == ' ' || c == '\t':
+			i++
+			continue
+		case c == '"' || c == '\'':
+			i++
+			var b strings.Builder
+			for i < len(query) && query[i] != c {
+				if query[i] == '\\' && i+1 < len(query) {
+					i++
+				}
+				b.WriteByte(query[i])
+				i++
+			}
+			if i >= len(query) {
+				return nil, &QueryError{query, start, "unterminated quoted string"}
+			}
+			i++
+			tokens = append(tokens, queryToken{qtString, b.String(), start})
+			afterOp = false
+			continue
+		case afterOp:
+			for i < len(query) && query[i] != ' ' && query[i] != '\t' && query[i] != ')' {
+				i++
+			}
+			tokens = append(tokens, queryToken{qtWord, query[start:i], start})
+			afterOp = false
+			continue
+		case c == '(':
+			tokens = append(tokens, queryToken{qtLParen, "(", start})
+			i++
+			continue
+		case c == ')':
+			tokens = append(tokens, queryToken{qtRParen, ")", start})
+			i++
+			continue
+		case strings.HasPrefix(query[i:], "&&"):
+			tokens = append(tokens, queryToken{qtAnd, "AND", start})
+			i += 2
+			continue
+		case strings.HasPrefix(query[i:], "||"):
+			tokens = append(tokens, queryToken{qtOr, "OR", start})
+			i += 2
+			continue
+		case strings.HasPrefix(query[i:], "!="):
+			tokens = append(tokens, queryToken{qtOp, "!=", start})
+			i += 2
+			afterOp = true
+			continue
+		case c == '!':
+			tokens = append(tokens, queryToken{qtNot, "NOT", start})
+			i++
+			continue
+		case c == ':' || c == '=' || c == '~':
+			tokens = append(tokens, queryToken{qtOp, string(c), start})
+			i++
+			afterOp = true
+			continue
+		}
+
+		for i < len(query) && !strings.ContainsRune(" \t()\"':=~!", rune(query[i])) {
+			i++
+		}
+		word := query[start:i]
+		switch strings.ToUpper(word) {
+		case "AND":
+			tokens = append(tokens, queryToken{qtAnd, "AND", start})
+		case "OR":
+			tokens = append(tokens, queryToken{qtOr, "OR", start})
+		case "NOT":
+			tokens = append(tokens, queryToken{qtNot, "NOT", start})
+		default:
+			tokens = append(tokens, queryToken{qtWord, word, start})
+		}
+	}
+	tokens = append(tokens, queryToken{qtEOF, "", len(query)})
+	return tokens, nil
+}
+
+type queryParser struct {
+	query  string
+	tokens []queryToken
+	pos    int
+}
+
+func (p *queryParser) peek() queryToken {
+	return p.tokens[p.pos]
+}
+
+func (p *queryParser) next() queryToken {
+	t := p.tokens[p.pos]
+	if t.Kind != qtEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *queryParser) errorAt(pos int, format string, args ...interface{}) error {
+	return &QueryError{Query: p.query, Pos: pos, Msg: fmt.Sprintf(format, args...)}
+}
+
+func (p *queryParser) parseOr() (queryNode, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().Kind == qtOr {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = queryBinary{Op: "OR", Left: left, Right: right}
+	}
+	return left, nil
+}
+
+func (p *queryParser) parseAnd() (queryNode, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		switch p.peek().Kind {
+		case qtAnd:
+			p.next()
+		case qtWord, qtLParen, qtNot:
+		default:
+			return left, nil
+		}
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = queryBinary{Op: "AND", Left: left, Right: right}
+	}
+}
+
+func (p *queryParser) parseUnary() (queryNode, error) {
+	t := p.peek()
+	switch t.Kind {
+	case qtNot:
+		p.next()
+		expr, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return queryNot{Expr: expr}, nil
+	case qtLParen:
+		p.next()
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.next(); closing.Kind != qtRParen {
+			return nil, p.errorAt(closing.Pos, "expected \")\" to close \"(\" at position %d", t.Pos+1)
+		}
+		return expr, nil
+	case qtWord:
+		return p.parseTerm()
+	case qtEOF:
+		return nil, p.errorAt(t.Pos, "unexpected end of query, expected a term such as field:apis")
+	default:
+		return nil, p.errorAt(t.Pos, "unexpected %q, expected a term such as field:apis", t.Value)
+	}
+}
+
+func (p *queryParser) parseTerm() (queryNode, error) {
+	key := p.next()
+	op := p.next()
+	if op.Kind != qtOp {
+		return nil, p.errorAt(op.Pos, "expected \":\", \"=\", \"!=\" or \"~\" after %q", key.Value)
+	}
+	value := p.next()
+	if value.Kind != qtWord && value.Kind != qtString || value.Value == "" {
+		return nil, p.errorAt(value.Pos, "missing value after %s%s", key.Value, op.Value)
+	}
+	return queryTerm{Key: key.Value, Op: op.Value, Value: value.Value, Pos: key.Pos, OpPos: op.Pos, ValuePos: value.Pos}, nil
+}
+
+// parseQuerySyntax parses a query without checking its keys and values.
+func parseQuerySyntax(query string) (queryNode, *queryParser, error) {
+	tokens, err := lexQuery(query)
+	if err != nil {
+		return nil, nil, err
+	}
+	p := &queryParser{query: query, tokens: tokens}
+	node, err := p.parseOr()
+	if err != nil {
+		return nil, nil, err
+	}
+	if t := p.peek(); t.Kind != qtEOF {
+		return nil, nil, p.errorAt(t.Pos, "unexpected %q", t.Value)
+	}
+	return node, p, nil
+}
+
+// parseQuery parses and validates a query against queryKeys and fieldMapping.
+func parseQuery(query string) (queryNode, error) {
+	node, p, err := parseQuerySyntax(query)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateQuery(p, node, true); err != nil {
+		return nil, err
+	}
+	return node, nil
+}
+
+func validateQuery(p *queryParser, node queryNode, topLevel bool) error {
+	switch n := node.(type) {
+	case queryBinary:
+		childTopLevel := topLevel && n.Op == "AND"
+		if err := validateQuery(p, n.Left, childTopLevel); err != nil {
+			return err
+		}
+		return validateQuery(p, n.Right, childTopLevel)
+	case queryNot:
+		return validateQuery(p, n.Expr, false)
+	case queryTerm:
+		return validateQueryTerm(p, n, topLevel)
+	}
+	return nil
+}
+
+func validateQueryTerm(p *queryParser, term queryTerm, topLevel bool) error {
+	key := strings.ToLower(term.Key)
+	ops, ok := queryKeys[key]
+	if !ok {
+		keys := make([]string, 0, len(queryKeys))
+		for k := range queryKeys {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		return p.errorAt(term.Pos, "unknown key %q%s, valid keys: %s", term.Key, didYouMean(term.Key, keys), strings.Join(keys, ", "))
+	}
+	validOp := false
+	for _, op := range ops {
+		validOp = validOp || op == term.Op
+	}
+	if !validOp {
+		return p.errorAt(term.OpPos, "%s does not support %q, use %s", key, term.Op, strings.Join(ops, " or "))
+	}
+	if queryModifiers[key] && !topLevel {
+		return p.errorAt(term.Pos, "%s applies to the whole query and cannot be used inside OR or NOT", key)
+	}
+
+	switch key {
+	case "field":
+		if _, err := resolveQueryField(term.Value); err != nil {
+			return p.errorAt(term.ValuePos, "%v", err)
+		}
+	case "sub":
+		if _, err := strconv.ParseBool(term.Value); err != nil {
+			return p.errorAt(term.ValuePos, "sub must be true or false, got %q", term.Value)
+		}
+	case "page":
+		if n, err := strconv.Atoi(term.Value); err != nil || n < 1 {
+			return p.errorAt(term.ValuePos, "page must be a positive number, got %q", term.Value)
+		}
+	}
+	return nil
+}
+
+// resolveQueryField maps a field name ("apis") or backend name ("apiPaths") to
+// the backend name.
+func resolveQueryField(name string) (string, error) {
+	if backend, ok := fieldMapping[name]; ok {
+		return backend, nil
+	}
+	names := make([]string, 0, len(fieldMapping))
+	for friendly, backend := range fieldMapping {
+		if backend == name {
+			return backend, nil
+		}
+		names = append(names, friendly)
+	}
+	sort.Strings(names)
+	return "", fmt.Errorf("unknown field %q%s, run \"jsmon query fields\" to list them", name, didYouMean(name, names))
+}
+
+// translateQuery renders a parsed query in the server's query format: terms as
+// key:value with backend field names, AND as juxtaposition and OR/NOT as
+// keywords.
+func translateQuery(node queryNode) string {
+	return translateQueryNode(node, "")
+}
+
+func translateQueryNode(node queryNode, parentOp string) string {
+	switch n := node.(type) {
+	case queryBinary:
+		var s string
+		if n.Op == "AND" {
+			s = translateQueryNode(n.Left, "AND") + " " + translateQueryNode(n.Right, "AND")
+		} else {
+			s = translateQueryNode(n.Left, "OR") + " OR " + translateQueryNode(n.Right, "OR")
+		}
+		if parentOp != "" && parentOp != n.Op {
+			return "(" + s + ")"
+		}
+		return s
+	case queryNot:
+		return "NOT " + translateQueryNode(n.Expr, "NOT")
+	case queryTerm:
+		key := strings.ToLower(n.Key)
+		value := n.Value
+		if key == "field" {
+			value, _ = resolveQueryField(value)
+		}
+		op := n.Op
+		if op == "=" {
+			op = ":"
+		}
+		if strings.ContainsAny(value, " \t()\"") {
+			value = strconv.Quote(value)
+		}
+		return key + op + value
+	}
+	return ""
+}
+
+// splitQueryPage removes the page term from the top-level AND chain of node
+// and returns the remaining query (nil if nothing is left) and the page, or 0
+// when the query does not set one.
+func splitQueryPage(node queryNode) (queryNode, int) {
+	switch n := node.(type) {
+	case queryTerm:
+		if strings.ToLower(n.Key) == "page" {
+			page, _ := strconv.Atoi(n.Value)
+			return nil, page
+		}
+	case queryBinary:
+		if n.Op != "AND" {
+			return node, 0
+		}
+		left, leftPage := splitQueryPage(n.Left)
+		right, rightPage := splitQueryPage(n.Right)
+		page := leftPage
+		if rightPage != 0 {
+			page = rightPage
+		}
+		switch {
+		case left == nil:
+			return right, page
+		case right == nil:
+			return left, page
+		}
+		return queryBinary{Op: "AND", Left: left, Right: right}, page
+	}
+	return node, 0
+}
+
+// compileQuery parses, validates and translates a -query string.
+func compileQuery(query string) (string, error) {
+	node, err := parseQuery(strings.TrimSpace(query))
+	if err != nil {
+		return "", err
+	}
+	return translateQuery(node), nil
+}
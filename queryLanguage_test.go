@@ -0,0 +1,137 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLexQuery(t *testing.T) {
+	tokens, err := lexQuery(`field:apis && (url~"a b" || !value!=x:y) or NOT sub=true`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []queryToken{
+		{qtWord, "field", 0}, {qtOp, ":", 5}, {qtWord, "apis", 6},
+		{qtAnd, "AND", 11},
+		{qtLParen, "(", 14},
+		{qtWord, "url", 15}, {qtOp, "~", 18}, {qtString, "a b", 19},
+		{qtOr, "OR", 25},
+		{qtNot, "NOT", 28}, {qtWord, "value", 29}, {qtOp, "!=", 34}, {qtWord, "x:y", 36},
+		{qtRParen, ")", 39},
+		{qtOr, "OR", 41},
+		{qtNot, "NOT", 44}, {qtWord, "sub", 48}, {qtOp, "=", 51}, {qtWord, "true", 52},
+		{qtEOF, "", 56},
+	}
+	if !reflect.DeepEqual(tokens, want) {
+		t.Errorf("got %v\nwant %v", tokens, want)
+	}
+
+	tokens, err = lexQuery(`value:'it\'s'`)
+	if err != nil || tokens[2].Value != "it's" {
+		t.Errorf("got %v, %v for an escaped quote", tokens, err)
+	}
+	if _, err := lexQuery(`value:"open`); err == nil || err.(*QueryError).Pos != 6 {
+		t.Errorf("got %v for an unterminated string, want an error at position 6", err)
+	}
+}
+
+func TestCompileQuery(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"field:apis", "field:apiPaths"},
+		{"field=apiPaths", "field:apiPaths"},
+		{"field:apis domain:example.com", "field:apiPaths domain:example.com"},
+		{"field:apis AND domain:example.com NOT value~/internal", "field:apiPaths domain:example.com NOT value~/internal"},
+		{"(field:emails OR field:guids) sub:true", "(field:emails OR field:guids) sub:true"},
+		{"field:emails || field:guids && domain:a.com", "field:emails OR (field:guids domain:a.com)"},
+		{"!(domain:a.com OR domain:b.com)", "NOT (domain:a.com OR domain:b.com)"},
+		{`value~"two words"`, `value~"two words"`},
+		{"domain!=example.com", "domain!=example.com"},
+		{"  field:apis  ", "field:apiPaths"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			got, err := compileQuery(tt.query)
+			if err != nil {
+				t.Fatalf("compileQuery: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompileQueryErrors(t *testing.T) {
+	tests := []struct {
+		query   string
+		pos     int
+		wantMsg string
+	}{
+		{"", 0, "unexpected end of query"},
+		{"field", 5, `expected ":", "=", "!=" or "~" after "field"`},
+		{"field:", 6, "missing value after field:"},
+		{"(field:apis", 11, `expected ")"`},
+		{"field:apis)", 10, `unexpected ")"`},
+		{"AND field:apis", 0, `unexpected "AND"`},
+		{"feild:apis", 0, `unknown key "feild" (did you mean "field"?)`},
+		{"field:apsi", 6, `unknown field "apsi" (did you mean "apis"?)`},
+		{"field~apis", 5, `field does not support "~"`},
+		{"field:apis OR sub:true", 14, "sub applies to the whole query"},
+		{"NOT page:2", 4, "page applies to the whole query"},
+		{"page:0", 5, "page must be a positive number"},
+		{"sub:maybe", 4, "sub must be true or false"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			_, err := compileQuery(tt.query)
+			queryErr, ok := err.(*QueryError)
+			if !ok {
+				t.Fatalf("got error %v, want a *QueryError", err)
+			}
+			if queryErr.Pos != tt.pos || !strings.Contains(queryErr.Msg, tt.wantMsg) {
+				t.Errorf("got %q at %d, want %q at %d", queryErr.Msg, queryErr.Pos, tt.wantMsg, tt.pos)
+			}
+		})
+	}
+}
+
+func TestQueryErrorCaret(t *testing.T) {
+	_, err := compileQuery("field:apsi")
+	want := "unknown field \"apsi\" (did you mean \"apis\"?), run \"jsmon query fields\" to list them\n  field:apsi\n        ^"
+	if err == nil || err.Error() != want {
+		t.Errorf("got %q, want %q", err, want)
+	}
+}
+
+func TestSplitQueryPage(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+		page  int
+	}{
+		{"field:apis", "field:apiPaths", 0},
+		{"field:apis page:3", "field:apiPaths", 3},
+		{"page:2 field:apis domain:a.com", "field:apiPaths domain:a.com", 2},
+		{"page:2", "", 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			node, err := parseQuery(tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			rest, page := splitQueryPage(node)
+			got := ""
+			if rest != nil {
+				got = translateQuery(rest)
+			}
+			if got != tt.want || page != tt.page {
+				t.Errorf("got %q and page %d, want %q and page %d", got, page, tt.want, tt.page)
+			}
+		})
+	}
+}
@@ -0,0 +1,379 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// rsearchInputTypes maps the input types reverse search accepts to the graph
+// node kind of their values.
+var rsearchInputTypes = map[string]string{
+	"emails":             "email",
+	"domainname":         "domain",
+	"extractedDomains":   "domain",
+	"extractedUrls":      "url",
+	"apiPaths":           "api",
+	"s3Domains":          "bucket",
+	"invalidS3Domains":   "bucket",
+	"ipv4Addresses":      "ip",
+	"ipv6Addresses":      "ip",
+	"guids":              "guid",
+	"validNodeModules":   "package",
+	"invalidNodeModules": "package",
+	"gqlQuery":           "graphql",
+	"gqlMutation":        "graphql",
+}
+
+var rsearchAliases = map[string]string{
+	"email":  "emails",
+	"domain": "domainname",
+	"url":    "extractedUrls",
+	"api":    "apiPaths",
+	"bucket": "s3Domains",
+	"ip":     "ipv4Addresses",
+	"guid":   "guids",
+}
+
+// rsearchPivots are the intelligence fields followed from a JS file to new
+// reverse search inputs when pivoting.
+var rsearchPivots = map[string]string{
+	"emails":           "emails",
+	"extractedDomains": "domainname",
+	"s3Domains":        "s3Domains",
+}
+
+// rsearchRecord is a JS file that contains a searched value.
+type rsearchRecord struct {
+	InputType string `json:"inputType"`
+	Input     string `json:"input"`
+	JsmonId   string `json:"jsmonId,omitempty"`
+	URL       string `json:"url"`
+	Depth     int    `json:"depth"`
+}
+
+// resolveRsearchType accepts an input type, a short alias ("email") or a
+// query field name ("apis") and returns the input type sent to the API.
+func resolveRsearchType(name string) (string, error) {
+	if _, ok := rsearchInputTypes[name]; ok {
+		return name, nil
+	}
+	if inputType, ok := rsearchAliases[strings.ToLower(name)]; ok {
+		return inputType, nil
+	}
+	if backend, ok := fieldMapping[name]; ok {
+		if _, ok := rsearchInputTypes[backend]; ok {
+			return backend, nil
+		}
+	}
+	var names []string
+	for inputType := range rsearchInputTypes {
+		names = append(names, inputType)
+	}
+	for alias := range rsearchAliases {
+		names = append(names, alias)
+	}
+	sort.Strings(names)
+	return "", fmt.Errorf("unsupported input type %q%s, supported types: %s", name, didYouMean(name, names), strings.Join(names, ", "))
+}
+
+// fetchRsearchBody sends a reverse search request and returns the response
+// body unparsed.
+func fetchRsearchBody(inputType, input, wkspId string) ([]byte, error) {
+	params := url.Values{}
+	params.Set("inputType", inputType)
+	params.Set("input", input)
+	params.Set("wkspId", wkspId)
+	endpoint := fmt.Sprintf("%s/getAllJsUrlsResults?%s", apiBaseURL, params.Encode())
+
+	req, err := http.NewRequest("POST", endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Jsmon-Key", strings.TrimSpace(getAPIKey()))
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %v", err)
+	}
+	if resp.StatusCode == http.StatusUnauthorized {
+		return nil, fmt.Errorf("wrong API key")
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("received status code %d: %s", resp.StatusCode, string(body))
+	}
+	return body, nil
+}
+
+// reverseSearch returns the JS files in the workspace that contain input.
+func reverseSearch(inputType, input, wkspId string) ([]rsearchRecord, error) {
+	body, err := fetchRsearchBody(inputType, input, wkspId)
+	if err != nil {
+		return nil, err
+	}
+	return parseRsearchResponse(body, inputType, input)
+}
+
+// parseRsearchResponse reads the JS files from a reverse search response,
+// which has the same shape as getAllAutomationResults: a message and a
+// results list of JS file entries. Any other shape is an error that includes
+// the body.
+func parseRsearchResponse(body []byte, inputType, input string) ([]rsearchRecord, error) {
+	var response struct {
+		Message string               `json:"message"`
+		Results []IntelligenceResult `json:"results"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("error parsing reverse search response: %v: %s", err, string(body))
+	}
+	if response.Results == nil {
+		if response.Message != "" {
+			return nil, fmt.Errorf("%s", response.Message)
+		}
+		return nil, fmt.Errorf("unrecognized reverse search response: %s", string(body))
+	}
+
+	records := make([]rsearchRecord, 0, len(response.Results))
+	for _, result := range response.Results {
+		if result.URL == "" && result.JsmonId == "" {
+			return nil, fmt.Errorf("unrecognized reverse search result without url or jsmonId: %s", string(body))
+		}
+		records = append(records, rsearchRecord{InputType: inputType, Input: input, JsmonId: result.JsmonId, URL: result.URL})
+	}
+	return records, nil
+}
+
+type rsearchInput struct {
+	InputType string
+	Value     string
+	Depth     int
+}
+
+// pivotReverseSearch runs reverse search for each input. Up to maxDepth times
+// it then looks up the intelligence of every JS file found and searches again
+// for the emails, domains and buckets those files contain. Every input and JS
+// file is added to g; the search stops before g would have more than maxNodes
+// nodes.
+func pivotReverseSearch(inputs []rsearchInput, maxDepth, maxNodes int, wkspId string, g *graph) ([]rsearchRecord, error) {
+	var records []rsearchRecord
+	searched := map[string]bool{}
+	expanded := map[string]bool{}
+	addNode := func(kind, label string) *graphNode {
+		node := g.addNodeLimit(kind, label, maxNodes)
+		if node == nil {
+			fmt.Fprintf(os.Stderr, "[WRN] Reached %d nodes, stopping the search\n", maxNodes)
+		}
+		return node
+	}
+
+	queue := inputs
+	for len(queue) > 0 {
+		input := queue[0]
+		queue = queue[1:]
+		key := input.InputType + "\x00" + input.Value
+		if searched[key] {
+			continue
+		}
+		searched[key] = true
+
+		inputNode := addNode(rsearchInputTypes[input.InputType], input.Value)
+		if inputNode == nil {
+			return records, nil
+		}
+		found, err := reverseSearch(input.InputType, input.Value, wkspId)
+		if err != nil {
+			return records, fmt.Errorf("error searching %s %s: %v", input.InputType, input.Value, err)
+		}
+		for _, record := range found {
+			record.Depth = input.Depth
+			if strings.TrimSpace(record.URL) == "" {
+				records = append(records, record)
+				continue
+			}
+			jsNode := addNode("jsfile", record.URL)
+			if jsNode == nil {
+				return records, nil
+			}
+			records = append(records, record)
+			if record.JsmonId != "" {
+				jsNode.Attrs = map[string]string{"jsmonId": record.JsmonId}
+			}
+			g.addEdge(inputNode, jsNode, "found in")
+
+			if input.Depth >= maxDepth || record.JsmonId == "" || expanded[record.JsmonId] {
+				continue
+			}
+			expanded[record.JsmonId] = true
+			var fields []string
+			for field := range rsearchPivots {
+				fields = append(fields, field)
+			}
+			sort.Strings(fields)
+			results, err := fetchIntelligenceResults("jsmonid", record.JsmonId, fields, 0, wkspId)
+			if err != nil {
+				return records, fmt.Errorf("error fetching intelligence for %s: %v", record.JsmonId, err)
+			}
+			for _, result := range results {
+				for _, field := range fields {
+					inputType := rsearchPivots[field]
+					for _, value := range result.Field(field).Strings() {
+						if strings.TrimSpace(value) == "" {
+							continue
+						}
+						valueNode := addNode(rsearchInputTypes[inputType], value)
+						if valueNode == nil {
+							return records, nil
+						}
+						g.addEdge(jsNode, valueNode, "contains")
+						queue = append(queue, rsearchInput{inputType, value, input.Depth + 1})
+					}
+				}
+			}
+		}
+	}
+	return records, nil
+}
+
+// readRsearchInputs collects values from the positional arguments and, if
+// set, from a file with one value per line.
+func readRsearchInputs(values []string, path string) ([]string, error) {
+	var inputs []string
+	for _, value := range values {
+		if value = strings.TrimSpace(value); value != "" {
+			inputs = append(inputs, value)
+		}
+	}
+	if path != "" {
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("error opening input file: %v", err)
+		}
+		defer file.Close()
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if line != "" && !strings.HasPrefix(line, "#") {
+				inputs = append(inputs, line)
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, fmt.Errorf("error reading input file: %v", err)
+		}
+	}
+	return inputs, nil
+}
+
+func printRsearchRecords(records []rsearchRecord) {
+	if len(records) == 0 {
+		fmt.Println("No results found")
+		return
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "DEPTH\tTYPE\tINPUT\tJSMON ID\tURL")
+	for _, record := range records {
+		jsmonId := record.JsmonId
+		if jsmonId == "" {
+			jsmonId = "-"
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\n", record.Depth, record.InputType, record.Input, jsmonId, record.URL)
+	}
+	w.Flush()
+}
+
+func runRsearchCommand(args []string) error {
+	fs := newCommandFlagSet("rsearch", "rsearch -t <type> <value>... [-f values.txt] [-depth N] [flags]")
+	inputTypeFlag := fs.String("t", "", "Input type, e.g. emails, domainname, apiPaths (or email, domain, api)")
+	file := fs.String("f", "", "File with one value per line")
+	wkspId := fs.String("wksp", *workspaceFlag, "Workspace ID")
+	depth := fs.Int("depth", 0, "Pivot this many times through the emails, domains and buckets of the JS files found")
+	maxNodes := fs.Int("max-nodes", 500, "Stop pivoting once the graph has this many nodes")
+	jsonOutput := fs.Bool("json", false, "Print the results as JSON")
+	graphOutput := fs.String("graph", "", "Write the search graph to this file (- for stdout)")
+	graphFormat := fs.String("format", "json", "Graph format for -graph: json, dot or graphml")
+	positional, err := parseCommandArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if *inputTypeFlag == "" {
+		fs.Usage()
+		return fmt.Errorf("no input type specified, use -t")
+	}
+	if *graphOutput != "" {
+		if err := checkGraphFormat(*graphFormat); err != nil {
+			return err
+		}
+	}
+	inputType, err := resolveRsearchType(*inputTypeFlag)
+	if err != nil {
+		return err
+	}
+	values, err := readRsearchInputs(positional, *file)
+	if err != nil {
+		return err
+	}
+	if len(values) == 0 {
+		return fmt.Errorf("no values to search, pass them as arguments or with -f")
+	}
+	if *depth < 0 {
+		return fmt.Errorf("-depth must not be negative")
+	}
+	if err := requireWorkspace(*wkspId); err != nil {
+		return err
+	}
+
+	inputs := make([]rsearchInput, len(values))
+	for i, value := range values {
+		inputs[i] = rsearchInput{InputType: inputType, Value: value}
+	}
+	g := newGraph()
+	records, err := pivotReverseSearch(inputs, *depth, *maxNodes, *wkspId, g)
+	if err != nil {
+		return err
+	}
+
+	if *graphOutput != "" {
+		var w io.Writer = os.Stdout
+		if *graphOutput != "-" {
+			file, err := os.Create(*graphOutput)
+			if err != nil {
+				return fmt.Errorf("error creating %s: %v", *graphOutput, err)
+			}
+			defer file.Close()
+			w = file
+		}
+		if err := writeGraph(w, g, *graphFormat); err != nil {
+			return err
+		}
+		if *graphOutput == "-" {
+			return nil
+		}
+	}
+	if *jsonOutput {
+		if records == nil {
+			records = []rsearchRecord{}
+		}
+		jsonData, err := json.MarshalIndent(records, "", "  ")
+		if err != nil {
+			return fmt.Errorf("error formatting JSON: %v", err)
+		}
+		fmt.Println(string(jsonData))
+		return nil
+	}
+	printRsearchRecords(records)
+	return nil
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseRsearchResponse(t *testing.T) {
+	body := `{"message":"ok","results":[{"jsmonId":"j1","url":"https://a.com/a.js","emails":["x@a.com"]},{"url":"https://b.com/b.js"}]}`
+	records, err := parseRsearchResponse([]byte(body), "emails", "x@a.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []rsearchRecord{
+		{InputType: "emails", Input: "x@a.com", JsmonId: "j1", URL: "https://a.com/a.js"},
+		{InputType: "emails", Input: "x@a.com", URL: "https://b.com/b.js"},
+	}
+	if !reflect.DeepEqual(records, want) {
+		t.Errorf("got %+v, want %+v", records, want)
+	}
+
+	records, err = parseRsearchResponse([]byte(`{"results":[]}`), "emails", "x@a.com")
+	if err != nil || len(records) != 0 {
+		t.Errorf("got %+v, %v for no results", records, err)
+	}
+}
+
+func TestParseRsearchResponseErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		wantErr string
+	}{
+		{"not json", `<html>`, "<html>"},
+		{"message only", `{"message":"workspace not found"}`, "workspace not found"},
+		{"unknown shape", `{"data":["https://a.com/a.js"]}`, `unrecognized reverse search response: {"data"`},
+		{"results of strings", `{"results":["https://a.com/a.js"]}`, `["https://a.com/a.js"]`},
+		{"result without url", `{"results":[{"emails":["x@a.com"]}]}`, "without url or jsmonId"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseRsearchResponse([]byte(tt.body), "emails", "x@a.com")
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("got error %v, want one containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestAddNodeLimit(t *testing.T) {
+	g := newGraph()
+	a := g.addNodeLimit("email", "a@x.com", 2)
+	if a == nil || g.addNodeLimit("jsfile", "https://x.com/a.js", 2) == nil {
+		t.Fatal("got nil below the limit")
+	}
+	if node := g.addNodeLimit("domain", "x.com", 2); node != nil {
+		t.Errorf("got %+v past the limit, want nil", node)
+	}
+	if node := g.addNodeLimit("email", "a@x.com", 2); node != a {
+		t.Errorf("got %+v for an existing node, want it returned", node)
+	}
+	if len(g.nodes) != 2 {
+		t.Errorf("got %d nodes, want 2", len(g.nodes))
+	}
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"regexp"
+	"strings"
+)
+
+var (
+	nonAlphanumeric = regexp.MustCompile("[^a-zA-Z0-9]+")
+	digitRuns       = regexp.MustCompile("[0-9]+")
+)
+
+// genericSubdomainLabels are subdomain labels that say nothing about the target
+// and are never turned into scan keywords.
+var genericSubdomainLabels = map[string]bool{
+	"www": true, "www2": true, "m": true, "api": true, "app": true, "apps": true,
+	"cdn": true, "static": true, "assets": true, "img": true, "images": true,
+	"media": true, "js": true, "css": true, "mail": true, "dev": true,
+	"test": true, "staging": true, "stage": true, "prod": true, "beta": true,
+	"admin": true, "portal": true, "secure": true, "auth": true, "login": true,
+	"shop": true, "store": true, "blog": true, "docs": true, "help": true,
+}
+
+const minKeywordLength = 3
+
+// extractRootWord returns the label of the registrable domain, so both
+// "shop.example.co.uk" and "https://example.com/app.js" yield "example". IP
+// addresses have none.
+func extractRootWord(domain string) string {
+	host := normalizeHost(domain)
+	if net.ParseIP(host) != nil {
+		return ""
+	}
+	registrable, err := registrableDomain(host)
+	if err != nil {
+		registrable = host
+	}
+	label := strings.Split(registrable, ".")[0]
+	return nonAlphanumeric.ReplaceAllString(label, "")
+}
+
+// expandKeywords returns the root word followed by variations of it: hyphen
+// splits ("my-shop" -> "my-shop", "shop"), the brand without digits
+// ("shop24" -> "shop") and subdomain labels that are not generic.
+func expandKeywords(domain string) []string {
+	host := normalizeHost(domain)
+	keywords := []string{}
+	if net.ParseIP(host) != nil {
+		return keywords
+	}
+	seen := map[string]bool{}
+	add := func(word string) {
+		word = strings.ToLower(strings.TrimSpace(word))
+		if len(word) < minKeywordLength || seen[word] {
+			return
+		}
+		seen[word] = true
+		keywords = append(keywords, word)
+	}
+	addVariations := func(label string) {
+		add(nonAlphanumeric.ReplaceAllString(label, ""))
+		if strings.Contains(label, "-") {
+			add(label)
+			for _, part := range strings.Split(label, "-") {
+				add(part)
+			}
+		}
+		if withoutDigits := strings.Trim(digitRuns.ReplaceAllString(label, ""), "-"); withoutDigits != label {
+			add(withoutDigits)
+			add(nonAlphanumeric.ReplaceAllString(withoutDigits, ""))
+		}
+	}
+
+	registrable, err := registrableDomain(host)
+	if err != nil {
+		registrable = host
+	}
+	addVariations(strings.Split(registrable, ".")[0])
+
+	subdomains := strings.TrimSuffix(strings.TrimSuffix(host, registrable), ".")
+	if subdomains != "" {
+		for _, label := range strings.Split(subdomains, ".") {
+			if !genericSubdomainLabels[label] {
+				addVariations(label)
+			}
+		}
+	}
+	return keywords
+}
+
+// scanWords picks the words for a domain scan when none were given explicitly.
+func scanWords(domain string, expand bool) []string {
+	if expand {
+		return expandKeywords(domain)
+	}
+	if rootWord := extractRootWord(domain); rootWord != "" {
+		return []string{rootWord}
+	}
+	return []string{}
+}
+
+// printScanPlan shows what a domain scan would send without calling the API.
+func printScanPlan(targets []domainScanTarget) {
+	for _, target := range targets {
+		registrable, err := registrableDomain(target.Domain)
+		if err != nil {
+			registrable = fmt.Sprintf("n/a (%v)", err)
+		}
+		fmt.Printf("[DRY] %s (registrable domain: %s) words: %s\n", target.Domain, registrable, strings.Join(target.Words, ","))
+	}
+}
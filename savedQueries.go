@@ -0,0 +1,169 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const savedQueriesFile = "queries.json"
+
+// savedQuery is a named -query string. Its query may contain {{name}}
+// placeholders that are filled in when it is run.
+type savedQuery struct {
+	Query       string `json:"query"`
+	Description string `json:"description,omitempty"`
+}
+
+var (
+	queryNamePattern   = regexp.MustCompile(`^[A-Za-z0-9][\w.-]*$`)
+	placeholderPattern = regexp.MustCompile(`\{\{\s*([A-Za-z_][\w-]*)\s*\}\}`)
+)
+
+// domainQueries are the built-in queries scoped to a single domain. Every
+// field in fieldMapping also gets a workspace-wide built-in of the same name.
+var domainQueries = map[string]savedQuery{
+	"domain-apis":      {"field:apis domain:{{domain}} sub:true", "API paths found on a domain and its subdomains"},
+	"domain-urls":      {"field:urls domain:{{domain}} sub:true", "URLs found on a domain and its subdomains"},
+	"domain-emails":    {"field:emails domain:{{domain}} sub:true", "Email addresses found on a domain and its subdomains"},
+	"domain-buckets":   {"(field:cloud-buckets OR field:bucket-takeovers) domain:{{domain}} sub:true", "Cloud buckets and bucket takeovers for a domain"},
+	"domain-graphql":   {"(field:gql-queries OR field:gql-mutations OR field:gql-fragments) domain:{{domain}}", "GraphQL operations found on a domain"},
+	"domain-takeovers": {"(field:bucket-takeovers OR field:node-modules-confusion) domain:{{domain}} sub:true", "Bucket takeovers and dependency confusion candidates for a domain"},
+}
+
+// builtinQueries returns the starter library shipped with the CLI.
+func builtinQueries() map[string]savedQuery {
+	queries := map[string]savedQuery{}
+	for name := range fieldMapping {
+		queries[name] = savedQuery{
+			Query:       "field:" + name,
+			Description: fieldDescriptions[name].Description,
+		}
+	}
+	for name, query := range domainQueries {
+		queries[name] = query
+	}
+	return queries
+}
+
+func loadSavedQueries() (map[string]savedQuery, error) {
+	path, err := configPath(savedQueriesFile)
+	if err != nil {
+		return nil, err
+	}
+	queries := map[string]savedQuery{}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return queries, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading %s: %v", path, err)
+	}
+	if err := json.Unmarshal(data, &queries); err != nil {
+		return nil, fmt.Errorf("error parsing %s: %v", path, err)
+	}
+	return queries, nil
+}
+
+func writeSavedQueries(queries map[string]savedQuery) error {
+	path, err := configPath(savedQueriesFile)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(queries, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error formatting JSON: %v", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0600); err != nil {
+		return fmt.Errorf("error writing %s: %v", path, err)
+	}
+	return nil
+}
+
+// findSavedQuery looks a query up among the saved queries first and the
+// built-in library second.
+func findSavedQuery(name string) (savedQuery, error) {
+	saved, err := loadSavedQueries()
+	if err != nil {
+		return savedQuery{}, err
+	}
+	if query, ok := saved[name]; ok {
+		return query, nil
+	}
+	builtins := builtinQueries()
+	if query, ok := builtins[name]; ok {
+		return query, nil
+	}
+	var names []string
+	for n := range saved {
+		names = append(names, n)
+	}
+	for n := range builtins {
+		names = append(names, n)
+	}
+	return savedQuery{}, fmt.Errorf("no saved query named %q%s, see \"jsmon query list\"", name, didYouMean(name, names))
+}
+
+// queryPlaceholders returns the placeholder names used in query, in order of
+// first use.
+func queryPlaceholders(query string) []string {
+	var names []string
+	seen := map[string]bool{}
+	for _, match := range placeholderPattern.FindAllStringSubmatch(query, -1) {
+		if !seen[match[1]] {
+			seen[match[1]] = true
+			names = append(names, match[1])
+		}
+	}
+	return names
+}
+
+// fillPlaceholders substitutes params into the {{name}} placeholders of query.
+// Values that would not parse as a single word are quoted.
+func fillPlaceholders(query string, params map[string]string) (string, error) {
+	var missing []string
+	for _, name := range queryPlaceholders(query) {
+		if _, ok := params[name]; !ok {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		flags := make([]string, len(missing))
+		for i, name := range missing {
+			flags[i] = "-p " + name + "=..."
+		}
+		return "", fmt.Errorf("missing value for %s, pass %s", strings.Join(missing, ", "), strings.Join(flags, " "))
+	}
+	return placeholderPattern.ReplaceAllStringFunc(query, func(match string) string {
+		value := params[placeholderPattern.FindStringSubmatch(match)[1]]
+		if value == "" || strings.ContainsAny(value, " \t()\"'") {
+			return strconv.Quote(value)
+		}
+		return value
+	}), nil
+}
+
+// validateSavedQuery checks a query before it is saved. Queries with
+// placeholders are only checked for syntax since their values are not known
+// yet.
+func validateSavedQuery(query string) error {
+	if len(queryPlaceholders(query)) == 0 {
+		_, err := compileQuery(query)
+		return err
+	}
+	_, _, err := parseQuerySyntax(strings.TrimSpace(query))
+	return err
+}
+
+func sortedQueryNames(queries map[string]savedQuery) []string {
+	names := make([]string, 0, len(queries))
+	for name := range queries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// domainScanTarget is one line of a -dL file: a domain and the words to scan it with.
+type domainScanTarget struct {
+	Domain string
+	Words  []string
+}
+
+type domainScanResult struct {
+	Target domainScanTarget
+	Err    error
+}
+
+// parseDomainList reads a -dL file. Each non-empty line holds a domain optionally
+// followed by key=value options, e.g. "example.co.uk words=example,exmpl".
+// Lines starting with # are ignored. Domains without words= fall back to
+// defaultWords, or to scanWords when defaultWords is empty.
+func parseDomainList(path string, defaultWords []string, expand bool) ([]domainScanTarget, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("error opening domain list: %v", err)
+	}
+	defer file.Close()
+
+	var targets []domainScanTarget
+	scanner := bufio.NewScanner(file)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		target := domainScanTarget{Domain: fields[0]}
+		for _, option := range fields[1:] {
+			parts := strings.SplitN(option, "=", 2)
+			if len(parts) != 2 {
+				return nil, fmt.Errorf("line %d: invalid option %q, expected key=value", lineNumber, option)
+			}
+			switch strings.ToLower(parts[0]) {
+			case "words":
+				target.Words = splitWords(parts[1])
+			default:
+				return nil, fmt.Errorf("line %d: unknown option %q", lineNumber, parts[0])
+			}
+		}
+
+		if len(target.Words) == 0 {
+			if len(defaultWords) > 0 {
+				target.Words = defaultWords
+			} else {
+				target.Words = scanWords(target.Domain, expand)
+			}
+		}
+		targets = append(targets, target)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading domain list: %v", err)
+	}
+	if len(targets) == 0 {
+		return nil, fmt.Errorf("no domains found in %s", path)
+	}
+	return targets, nil
+}
+
+// splitWords splits a comma-separated word list, dropping empty entries.
+func splitWords(list string) []string {
+	words := []string{}
+	for _, word := range strings.Split(list, ",") {
+		word = strings.TrimSpace(word)
+		if word != "" {
+			words = append(words, word)
+		}
+	}
+	return words
+}
+
+// scanDomainList runs automateScanDomain for every target with at most
+// concurrency scans in flight, then reports per-domain results. It returns an
+// error if any domain failed so the caller can exit non-zero.
+func scanDomainList(targets []domainScanTarget, wkspId string, concurrency int) error {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	results := make([]domainScanResult, len(targets))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i, target := range targets {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, target domainScanTarget) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			results[i] = domainScanResult{
+				Target: target,
+				Err:    automateScanDomain(target.Domain, target.Words, wkspId),
+			}
+		}(i, target)
+	}
+	wg.Wait()
+
+	failed := 0
+	for _, result := range results {
+		if result.Err != nil {
+			failed++
+			fmt.Printf("[ERR] %s: %v\n", result.Target.Domain, result.Err)
+		}
+	}
+	fmt.Printf("[INF] %d/%d domains scanned successfully\n", len(targets)-failed, len(targets))
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d domains failed to scan", failed, len(targets))
+	}
+	return nil
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+)
+
+// scanLocalFile runs the rules over one file and returns its findings in the
+// shape of a scanner result. ok is false when nothing was found.
+func scanLocalFile(path, content string, rules []secretRule, scannedAt string) (DataItem, bool) {
+	item := DataItem{URL: path, CreatedAt: scannedAt}
+	for i := range rules {
+		seen := map[string]bool{}
+		var words []string
+		for _, secret := range rules[i].find(path, content) {
+			if !seen[secret] {
+				seen[secret] = true
+				words = append(words, secret)
+			}
+		}
+		if len(words) > 0 {
+			item.ModuleName = append(item.ModuleName, rules[i].ID)
+			item.DetectedWords = append(item.DetectedWords, DetectedWord{Name: rules[i].ID, Words: words})
+		}
+	}
+	return item, len(item.DetectedWords) > 0
+}
+
+// scanLocalFiles scans every JS file under the given paths.
+func scanLocalFiles(paths []string, rules []secretRule) ([]DataItem, error) {
+	scannedAt := time.Now().UTC().Format(time.RFC3339)
+	var items []DataItem
+	for _, path := range paths {
+		files, err := localJSFiles(path)
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range files {
+			data, err := os.ReadFile(file)
+			if err != nil {
+				return nil, fmt.Errorf("error reading %s: %v", file, err)
+			}
+			if item, ok := scanLocalFile(file, string(data), rules, scannedAt); ok {
+				items = append(items, item)
+			}
+		}
+	}
+	return items, nil
+}
+
+// mergeDataItems appends the local findings to the server's. A local word
+// already reported for the same URL or file under the same module is dropped,
+// as is a local item left without words.
+func mergeDataItems(server, local []DataItem) []DataItem {
+	known := map[string]bool{}
+	key := func(url, module, word string) string {
+		return url + "\x00" + module + "\x00" + word
+	}
+	for _, item := range server {
+		for _, detected := range item.DetectedWords {
+			for _, word := range detected.Words {
+				known[key(item.URL, detected.Name, word)] = true
+			}
+		}
+	}
+	merged := append([]DataItem{}, server...)
+	for _, item := range local {
+		var detectedWords []DetectedWord
+		var modules []string
+		for _, detected := range item.DetectedWords {
+			var words []string
+			for _, word := range detected.Words {
+				if k := key(item.URL, detected.Name, word); !known[k] {
+					known[k] = true
+					words = append(words, word)
+				}
+			}
+			if len(words) > 0 {
+				detectedWords = append(detectedWords, DetectedWord{Name: detected.Name, Words: words})
+				modules = append(modules, detected.Name)
+			}
+		}
+		if len(detectedWords) > 0 {
+			item.DetectedWords, item.ModuleName = detectedWords, modules
+			merged = append(merged, item)
+		}
+	}
+	return merged
+}
+
+func printDataItems(items []DataItem) error {
+	if items == nil {
+		items = []DataItem{}
+	}
+	jsonData, err := json.MarshalIndent(items, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error formatting JSON: %v", err)
+	}
+	fmt.Println(string(jsonData))
+	return nil
+}
+
+func printSecretRules(rules []secretRule) {
+	sorted := append([]secretRule{}, rules...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].ID < sorted[j].ID })
+	for _, rule := range sorted {
+		fmt.Printf("%-20s %s\n", rule.ID, rule.Description)
+	}
+}
+
+func runScanLocalCommand(args []string) error {
+	fs := newCommandFlagSet("scan-local", "scan-local <file.js|dir>... [-rules pack.yaml]... [-merge -wksp <id>] [-json]")
+	var rulePacks stringSliceFlag
+	fs.Var(&rulePacks, "rules", "YAML or JSON rule pack to add (can be used multiple times)")
+	noDefault := fs.Bool("no-default", false, "Do not load the built-in rules")
+	listRules := fs.Bool("list-rules", false, "List the loaded rules and exit")
+	merge := fs.Bool("merge", false, "Merge the findings with the workspace's scanner results, dropping duplicates")
+	wkspId := fs.String("wksp", *workspaceFlag, "Workspace ID for -merge")
+	jsonOutput := fs.Bool("json", false, "Print the results as JSON in the -secrets format")
+	fail := fs.Bool("fail", false, "Exit non-zero if anything is found")
+	var filterFlags secretsFilterFlags
+	filterFlags.register(fs)
+	positional, err := parseCommandArgs(fs, args)
+	if err != nil {
+		return err
+	}
+
+	rules, err := loadSecretRules(rulePacks, *noDefault)
+	if err != nil {
+		return err
+	}
+	if *listRules {
+		printSecretRules(rules)
+		return nil
+	}
+	if len(positional) == 0 {
+		fs.Usage()
+		return fmt.Errorf("scan-local needs a JS file or directory")
+	}
+	filter, err := filterFlags.filter()
+	if err != nil {
+		return err
+	}
+	by, grouped, err := filterFlags.grouping()
+	if err != nil {
+		return err
+	}
+
+	items, err := scanLocalFiles(positional, rules)
+	if err != nil {
+		return err
+	}
+	if *merge {
+		if getAPIKey() == "" {
+			if err := loadAPIKey(); err != nil {
+				return fmt.Errorf("-merge needs an API key: %v", err)
+			}
+		}
+		if err := requireWorkspace(*wkspId); err != nil {
+			return err
+		}
+		server, err := fetchScannerResults(*wkspId)
+		if err != nil {
+			return fmt.Errorf("error fetching scanner results: %v", err)
+		}
+		items = mergeDataItems(server.Data, items)
+	}
+	items = filter.apply(items)
+
+	store, err := loadTriageStore()
+	if err != nil {
+		return err
+	}
+	findings := secretFindings(items)
+	store.apply(findings)
+	sortFindings(findings)
+	switch {
+	case grouped:
+		err = printSecretsGroups(groupFindings(findings, by, filterFlags.Top), by, *jsonOutput)
+	case *jsonOutput:
+		err = printDataItems(items)
+	default:
+		err = printFindings(findings, false)
+	}
+	if err != nil {
+		return err
+	}
+	if *fail && len(findings) > 0 {
+		return fmt.Errorf("%d findings", len(findings))
+	}
+	return nil
+}
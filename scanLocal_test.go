@@ -0,0 +1,85 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func testRules(t *testing.T) []secretRule {
+	t.Helper()
+	pack := secretRulePack{
+		Rules: []secretRule{
+			{ID: "corp-token", Regex: `corp_([a-z0-9]{16})`, SecretGroup: 1, Keywords: []string{"CORP_"}, Entropy: 3,
+				Allowlist: secretAllowlist{Stopwords: []string{"test"}, Regexes: []string{`^f0`}}},
+			{ID: "any-key", Regex: `key_[A-Za-z0-9]{8}`},
+		},
+		Allowlist: secretAllowlist{Paths: []string{"*/vendor/*"}},
+	}
+	if err := compileRulePack(&pack); err != nil {
+		t.Fatal(err)
+	}
+	return pack.Rules
+}
+
+func TestScanLocalFile(t *testing.T) {
+	rules := testRules(t)
+	content := `a = "corp_a8f3k2q9z7x1m4p6"; b = "corp_aaaaaaaaaaaaaaab"; c = "corp_teste8f3k2q9z7x1";
+d = "corp_f0e1d2c3b4a59687"; e = "key_Ab12Cd34"; f = "corp_a8f3k2q9z7x1m4p6"; g = "key_Ab12Cd34"`
+
+	item, ok := scanLocalFile("src/app.js", content, rules, "2026-01-02T00:00:00Z")
+	want := DataItem{
+		URL:        "src/app.js",
+		CreatedAt:  "2026-01-02T00:00:00Z",
+		ModuleName: []string{"corp-token", "any-key"},
+		DetectedWords: []DetectedWord{
+			{Name: "corp-token", Words: []string{"a8f3k2q9z7x1m4p6"}},
+			{Name: "any-key", Words: []string{"key_Ab12Cd34"}},
+		},
+	}
+	if !ok || !reflect.DeepEqual(item, want) {
+		t.Errorf("got %+v, %v, want %+v", item, ok, want)
+	}
+
+	if item, ok := scanLocalFile("lib/vendor/x.js", content, rules, ""); ok {
+		t.Errorf("got %+v in an allowlisted path", item)
+	}
+	if item, ok := scanLocalFile("src/app.js", `e = "corp_a8f3k2q9z7x1m4p6".replace("CORP", "")`, rules[:1], ""); !ok || len(item.DetectedWords) != 1 {
+		t.Errorf("got %+v, %v with a case-insensitive keyword", item, ok)
+	}
+	if item, ok := scanLocalFile("src/app.js", `x = "a8f3k2q9z7x1m4p6"`, rules, ""); ok {
+		t.Errorf("got %+v without the keyword", item)
+	}
+}
+
+func TestMergeDataItems(t *testing.T) {
+	server := []DataItem{
+		{JsmonId: "j1", URL: "https://x.com/app.js", ModuleName: []string{"any-key"}, DetectedWords: []DetectedWord{{Name: "any-key", Words: []string{"key_1", "key_2"}}}},
+	}
+	local := []DataItem{
+		// Already reported for this URL and module.
+		{URL: "https://x.com/app.js", ModuleName: []string{"any-key"}, DetectedWords: []DetectedWord{{Name: "any-key", Words: []string{"key_1"}}}},
+		// Same word in another file, and under another module.
+		{URL: "dist/app.js", ModuleName: []string{"any-key", "corp-token"}, DetectedWords: []DetectedWord{
+			{Name: "any-key", Words: []string{"key_1", "key_3"}},
+			{Name: "corp-token", Words: []string{"key_2"}},
+		}},
+		// Duplicate local item.
+		{URL: "dist/app.js", ModuleName: []string{"any-key"}, DetectedWords: []DetectedWord{{Name: "any-key", Words: []string{"key_3"}}}},
+		// Partly known.
+		{URL: "https://x.com/app.js", ModuleName: []string{"any-key"}, DetectedWords: []DetectedWord{{Name: "any-key", Words: []string{"key_2", "key_4"}}}},
+	}
+	want := []DataItem{
+		server[0],
+		{URL: "dist/app.js", ModuleName: []string{"any-key", "corp-token"}, DetectedWords: []DetectedWord{
+			{Name: "any-key", Words: []string{"key_1", "key_3"}},
+			{Name: "corp-token", Words: []string{"key_2"}},
+		}},
+		{URL: "https://x.com/app.js", ModuleName: []string{"any-key"}, DetectedWords: []DetectedWord{{Name: "any-key", Words: []string{"key_4"}}}},
+	}
+	if got := mergeDataItems(server, local); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+	if got := mergeDataItems(nil, local[:1]); !reflect.DeepEqual(got, local[:1]) {
+		t.Errorf("got %+v without server results", got)
+	}
+}
@@ -14,13 +14,12 @@ type ScannerResult struct {
 	Data    []DataItem `json:"data"`
 }
 
-func getScannerResults(wkspId string) {
+func fetchScannerResults(wkspId string) (ScannerResult, error) {
 	endpoint := fmt.Sprintf("%s/getScannerResults?wkspId=%s", apiBaseURL, wkspId)
 
 	req, err := http.NewRequest("GET", endpoint, nil)
 	if err != nil {
-		fmt.Println("Error creating request:", err)
-		return
+		return ScannerResult{}, fmt.Errorf("error creating request: %v", err)
 	}
 
 	req.Header.Set("X-Jsmon-Key", strings.TrimSpace(getAPIKey()))
@@ -28,32 +27,40 @@ func getScannerResults(wkspId string) {
 	client := &http.Client{}
 	resp, err := client.Do(req)
 	if err != nil {
-		fmt.Println("Error sending request:", err)
-		return
+		return ScannerResult{}, fmt.Errorf("error sending request: %v", err)
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode == http.StatusUnauthorized {
+		return ScannerResult{}, fmt.Errorf("wrong API key")
+	}
+
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		fmt.Println("Error reading response:", err)
-		return
+		return ScannerResult{}, fmt.Errorf("error reading response: %v", err)
 	}
 
 	var result ScannerResult
-
 	err = json.Unmarshal(body, &result)
 	if err != nil {
-		fmt.Println("Error parsing JSON:", err)
-		return
+		return ScannerResult{}, fmt.Errorf("error parsing JSON: %v", err)
+	}
+	return result, nil
+}
+
+func getScannerResults(wkspId string) error {
+	result, err := fetchScannerResults(wkspId)
+	if err != nil {
+		return err
 	}
 
 	fmt.Println("Message:", result.Message)
 	prettyJSON, err := json.MarshalIndent(result.Data, "", "  ")
 	if err != nil {
-		fmt.Println("Error creating JSON:", err)
-		return
+		return fmt.Errorf("error creating JSON: %v", err)
 	}
 
 	// Print the pretty JSON output
 	fmt.Printf("Data:\n%s\n", prettyJSON)
+	return nil
 }
@@ -0,0 +1,210 @@
+package main
+
+import (
+	_ "embed"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+//go:embed secret_rules.yaml
+var defaultSecretRulesData []byte
+
+// secretAllowlist skips secrets that match a regex, contain a stopword or are
+// found in a file whose path matches a glob.
+type secretAllowlist struct {
+	Regexes   []string `json:"regexes,omitempty"`
+	Paths     []string `json:"paths,omitempty"`
+	Stopwords []string `json:"stopwords,omitempty"`
+
+	regexes []*regexp.Regexp
+	paths   []*regexp.Regexp
+}
+
+// secretRule is one rule of a rule pack. See secret_rules.yaml for the
+// format.
+type secretRule struct {
+	ID          string          `json:"id"`
+	Description string          `json:"description,omitempty"`
+	Regex       string          `json:"regex"`
+	SecretGroup int             `json:"secretGroup,omitempty"`
+	Keywords    []string        `json:"keywords,omitempty"`
+	Entropy     float64         `json:"entropy,omitempty"`
+	Allowlist   secretAllowlist `json:"allowlist,omitempty"`
+
+	pattern *regexp.Regexp
+	// packAllowlist is the top-level allowlist of the pack the rule is from.
+	packAllowlist *secretAllowlist
+}
+
+type secretRulePack struct {
+	Rules     []secretRule    `json:"rules"`
+	Allowlist secretAllowlist `json:"allowlist,omitempty"`
+}
+
+func (a *secretAllowlist) compile() error {
+	for _, expr := range a.Regexes {
+		pattern, err := regexp.Compile(expr)
+		if err != nil {
+			return fmt.Errorf("invalid allowlist regex %q: %v", expr, err)
+		}
+		a.regexes = append(a.regexes, pattern)
+	}
+	for _, glob := range a.Paths {
+		pattern, err := globToRegexp(filepath.ToSlash(glob))
+		if err != nil {
+			return fmt.Errorf("invalid allowlist path %q: %v", glob, err)
+		}
+		a.paths = append(a.paths, pattern)
+	}
+	return nil
+}
+
+func (a *secretAllowlist) allowsPath(path string) bool {
+	path = filepath.ToSlash(path)
+	for _, pattern := range a.paths {
+		if pattern.MatchString(path) {
+			return true
+		}
+	}
+	return false
+}
+
+func (a *secretAllowlist) allowsSecret(secret string) bool {
+	for _, pattern := range a.regexes {
+		if pattern.MatchString(secret) {
+			return true
+		}
+	}
+	lower := strings.ToLower(secret)
+	for _, stopword := range a.Stopwords {
+		if strings.Contains(lower, strings.ToLower(stopword)) {
+			return true
+		}
+	}
+	return false
+}
+
+// compileRulePack validates the rules of a pack and compiles their patterns.
+func compileRulePack(pack *secretRulePack) error {
+	if err := pack.Allowlist.compile(); err != nil {
+		return err
+	}
+	for i := range pack.Rules {
+		rule := &pack.Rules[i]
+		if rule.ID == "" || rule.Regex == "" {
+			return fmt.Errorf("rule %d: id and regex are required", i+1)
+		}
+		pattern, err := regexp.Compile(rule.Regex)
+		if err != nil {
+			return fmt.Errorf("rule %s: invalid regex: %v", rule.ID, err)
+		}
+		if rule.SecretGroup < 0 || rule.SecretGroup > pattern.NumSubexp() {
+			return fmt.Errorf("rule %s: secretGroup %d does not exist in the regex", rule.ID, rule.SecretGroup)
+		}
+		if err := rule.Allowlist.compile(); err != nil {
+			return fmt.Errorf("rule %s: %v", rule.ID, err)
+		}
+		rule.pattern = pattern
+		rule.packAllowlist = &pack.Allowlist
+	}
+	return nil
+}
+
+// find returns the secrets the rule matches in the content of the file at
+// path. An empty path skips the path allowlists.
+func (r *secretRule) find(path, content string) []string {
+	if path != "" && (r.Allowlist.allowsPath(path) || r.packAllowlist.allowsPath(path)) {
+		return nil
+	}
+	if len(r.Keywords) > 0 {
+		lower := strings.ToLower(content)
+		found := false
+		for _, keyword := range r.Keywords {
+			if strings.Contains(lower, strings.ToLower(keyword)) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil
+		}
+	}
+
+	var secrets []string
+	for _, match := range r.pattern.FindAllStringSubmatch(content, -1) {
+		secret := match[r.SecretGroup]
+		if secret == "" || r.Entropy > 0 && shannonEntropy(secret) < r.Entropy {
+			continue
+		}
+		if r.Allowlist.allowsSecret(secret) || r.packAllowlist.allowsSecret(secret) {
+			continue
+		}
+		secrets = append(secrets, secret)
+	}
+	return secrets
+}
+
+var (
+	defaultRulePack     secretRulePack
+	defaultRulePackErr  error
+	defaultRulePackOnce sync.Once
+)
+
+// defaultSecretRules returns the rules of the embedded default pack.
+func defaultSecretRules() ([]secretRule, error) {
+	defaultRulePackOnce.Do(func() {
+		if err := decodeConfigData("secret_rules.yaml", defaultSecretRulesData, &defaultRulePack); err != nil {
+			defaultRulePackErr = err
+			return
+		}
+		if err := compileRulePack(&defaultRulePack); err != nil {
+			defaultRulePackErr = fmt.Errorf("secret_rules.yaml: %v", err)
+		}
+	})
+	return defaultRulePack.Rules, defaultRulePackErr
+}
+
+// loadSecretRules returns the default rules, unless noDefault is set, followed
+// by the rules of each pack file. A rule with the ID of an earlier rule
+// replaces it.
+func loadSecretRules(paths []string, noDefault bool) ([]secretRule, error) {
+	var rules []secretRule
+	index := map[string]int{}
+	add := func(rule secretRule) {
+		if i, ok := index[rule.ID]; ok {
+			rules[i] = rule
+			return
+		}
+		index[rule.ID] = len(rules)
+		rules = append(rules, rule)
+	}
+
+	if !noDefault {
+		defaults, err := defaultSecretRules()
+		if err != nil {
+			return nil, err
+		}
+		for _, rule := range defaults {
+			add(rule)
+		}
+	}
+	for _, path := range paths {
+		pack := &secretRulePack{}
+		if err := decodeConfigFile(path, pack); err != nil {
+			return nil, err
+		}
+		if err := compileRulePack(pack); err != nil {
+			return nil, fmt.Errorf("%s: %v", path, err)
+		}
+		for _, rule := range pack.Rules {
+			add(rule)
+		}
+	}
+	if len(rules) == 0 {
+		return nil, fmt.Errorf("no secret rules loaded")
+	}
+	return rules, nil
+}
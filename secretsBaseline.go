@@ -0,0 +1,171 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const defaultIgnoreFile = ".jsmonignore"
+
+// secretsBaseline is a snapshot of known findings. Findings in the baseline
+// are not reported as new.
+type secretsBaseline struct {
+	GeneratedAt string          `json:"generatedAt"`
+	Findings    []secretFinding `json:"findings"`
+}
+
+func writeBaseline(path string, findings []secretFinding) error {
+	baseline := secretsBaseline{GeneratedAt: time.Now().UTC().Format(time.RFC3339), Findings: findings}
+	if baseline.Findings == nil {
+		baseline.Findings = []secretFinding{}
+	}
+	for i := range baseline.Findings {
+		baseline.Findings[i].Status = ""
+		baseline.Findings[i].Note = ""
+	}
+	data, err := json.MarshalIndent(baseline, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error formatting JSON: %v", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("error writing %s: %v", path, err)
+	}
+	return nil
+}
+
+func loadBaseline(path string) (secretsBaseline, error) {
+	var baseline secretsBaseline
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return baseline, fmt.Errorf("error reading baseline: %v", err)
+	}
+	if err := json.Unmarshal(data, &baseline); err != nil {
+		return baseline, fmt.Errorf("error parsing baseline %s: %v", path, err)
+	}
+	return baseline, nil
+}
+
+// covers reports whether the baseline holds finding. Besides the finding ID,
+// the module, word and URL are compared so that a rescan of an unchanged file
+// under a new jsmon ID is still covered.
+func (b secretsBaseline) covers(finding secretFinding) bool {
+	for _, known := range b.Findings {
+		if known.ID == finding.ID ||
+			known.Module == finding.Module && known.Word == finding.Word && known.URL == finding.URL {
+			return true
+		}
+	}
+	return false
+}
+
+// ignoreRule is one line of a .jsmonignore file: "module:<name>",
+// "url:<glob>" or "word:<regex>".
+type ignoreRule struct {
+	Kind    string
+	Value   string
+	Pattern *regexp.Regexp
+}
+
+// globToRegexp converts a glob in which * matches any run of characters
+// (including /) and ? matches one character.
+func globToRegexp(glob string) (*regexp.Regexp, error) {
+	quoted := regexp.QuoteMeta(glob)
+	quoted = strings.ReplaceAll(quoted, `\*`, `.*`)
+	quoted = strings.ReplaceAll(quoted, `\?`, `.`)
+	return regexp.Compile("^" + quoted + "$")
+}
+
+// loadIgnoreRules reads a .jsmonignore file. Blank lines and lines starting
+// with # are skipped. A missing file is only an error when required is set.
+func loadIgnoreRules(path string, required bool) ([]ignoreRule, error) {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) && !required {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error opening ignore file: %v", err)
+	}
+	defer file.Close()
+
+	var rules []ignoreRule
+	scanner := bufio.NewScanner(file)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		i := strings.Index(line, ":")
+		if i < 0 {
+			return nil, fmt.Errorf("%s:%d: expected module:, url: or word: rule", path, lineNumber)
+		}
+		rule := ignoreRule{Kind: strings.ToLower(strings.TrimSpace(line[:i])), Value: strings.TrimSpace(line[i+1:])}
+		switch rule.Kind {
+		case "module":
+		case "url":
+			if rule.Pattern, err = globToRegexp(rule.Value); err != nil {
+				return nil, fmt.Errorf("%s:%d: invalid URL glob: %v", path, lineNumber, err)
+			}
+		case "word":
+			if rule.Pattern, err = regexp.Compile(rule.Value); err != nil {
+				return nil, fmt.Errorf("%s:%d: invalid word regex: %v", path, lineNumber, err)
+			}
+		default:
+			return nil, fmt.Errorf("%s:%d: unknown rule %q, use module:, url: or word:", path, lineNumber, rule.Kind)
+		}
+		rules = append(rules, rule)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading ignore file: %v", err)
+	}
+	return rules, nil
+}
+
+func (r ignoreRule) matches(finding secretFinding) bool {
+	switch r.Kind {
+	case "module":
+		return strings.EqualFold(r.Value, finding.Module)
+	case "url":
+		return r.Pattern.MatchString(finding.URL)
+	case "word":
+		return r.Pattern.MatchString(finding.Word)
+	}
+	return false
+}
+
+// suppressFindings drops the findings covered by the baseline or matched by
+// an ignore rule and returns the rest with the number dropped.
+func suppressFindings(findings []secretFinding, baseline *secretsBaseline, rules []ignoreRule) ([]secretFinding, int) {
+	var remaining []secretFinding
+	suppressed := 0
+findings:
+	for _, finding := range findings {
+		if baseline != nil && baseline.covers(finding) {
+			suppressed++
+			continue
+		}
+		for _, rule := range rules {
+			if rule.matches(finding) {
+				suppressed++
+				continue findings
+			}
+		}
+		remaining = append(remaining, finding)
+	}
+	return remaining, suppressed
+}
+
+// checkNewFindings returns an error, so the command exits non-zero in CI, if
+// findings remain after suppression and a baseline or -fail was given.
+func checkNewFindings(findings []secretFinding, baseline *secretsBaseline, fail bool) error {
+	if len(findings) > 0 && (baseline != nil || fail) {
+		return fmt.Errorf("%d new findings", len(findings))
+	}
+	return nil
+}
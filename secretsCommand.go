@@ -0,0 +1,312 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+)
+
+// fetchFindings fetches the scanner results of a workspace that match filter
+// as findings with their triage status filled in.
+func fetchFindings(wkspId string, filter secretsFilter) ([]secretFinding, triageStore, error) {
+	result, err := fetchScannerResults(wkspId)
+	if err != nil {
+		return nil, nil, err
+	}
+	store, err := loadTriageStore()
+	if err != nil {
+		return nil, nil, err
+	}
+	findings := secretFindings(filter.apply(result.Data))
+	store.apply(findings)
+	sortFindings(findings)
+	return findings, store, nil
+}
+
+func printFindings(findings []secretFinding, jsonOutput bool) error {
+	if jsonOutput {
+		if findings == nil {
+			findings = []secretFinding{}
+		}
+		jsonData, err := json.MarshalIndent(findings, "", "  ")
+		if err != nil {
+			return fmt.Errorf("error formatting JSON: %v", err)
+		}
+		fmt.Println(string(jsonData))
+		return nil
+	}
+	if len(findings) == 0 {
+		fmt.Println("No findings")
+		return nil
+	}
+	verified := false
+	for _, f := range findings {
+		verified = verified || f.Verdict != ""
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	if verified {
+		fmt.Fprintln(w, "ID\tSTATUS\tVERDICT\tCONFIDENCE\tMODULE\tWORD\tURL")
+	} else {
+		fmt.Fprintln(w, "ID\tSTATUS\tMODULE\tWORD\tURL")
+	}
+	for _, f := range findings {
+		if verified {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%.2f\t%s\t%s\t%s\n", f.ID, f.Status, f.Verdict, f.Confidence, f.Module, f.Word, f.URL)
+		} else {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", f.ID, f.Status, f.Module, f.Word, f.URL)
+		}
+	}
+	return w.Flush()
+}
+
+func runSecretsCommand(args []string) error {
+	if len(args) > 0 {
+		switch args[0] {
+		case "triage":
+			return runSecretsTriage(args[1:])
+		case "mark":
+			return runSecretsMark(args[1:])
+		case "baseline":
+			return runSecretsBaseline(args[1:])
+		}
+	}
+
+	fs := newCommandFlagSet("secrets", "secrets [triage|mark|baseline] [-status new|confirmed|false-positive|fixed|all] [-module m] [-domain d] [-since 7d] [-group module|domain] [-top N] [-baseline file] [-json]")
+	wkspId := fs.String("wksp", *workspaceFlag, "Workspace ID")
+	status := fs.String("status", "all", "Only show findings with this triage status")
+	jsonOutput := fs.Bool("json", false, "Print the findings as JSON")
+	baselinePath := fs.String("baseline", "", "Hide findings in this baseline and exit non-zero if any others remain")
+	ignorePath := fs.String("ignore", defaultIgnoreFile, "Suppression rules file (module:, url: and word: rules)")
+	fail := fs.Bool("fail", false, "Exit non-zero if any findings remain after filtering")
+	var filterFlags secretsFilterFlags
+	filterFlags.register(fs)
+	positional, err := parseCommandArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) > 0 {
+		return fmt.Errorf("unknown secrets command %q, use triage, mark or baseline", positional[0])
+	}
+	if *status != "all" {
+		if *status, err = parseTriageStatus(*status); err != nil {
+			return err
+		}
+	}
+	filter, err := filterFlags.filter()
+	if err != nil {
+		return err
+	}
+	by, grouped, err := filterFlags.grouping()
+	if err != nil {
+		return err
+	}
+	if err := requireWorkspace(*wkspId); err != nil {
+		return err
+	}
+
+	var baseline *secretsBaseline
+	if *baselinePath != "" {
+		loaded, err := loadBaseline(*baselinePath)
+		if err != nil {
+			return err
+		}
+		baseline = &loaded
+	}
+	rules, err := loadIgnoreRules(*ignorePath, *ignorePath != defaultIgnoreFile)
+	if err != nil {
+		return err
+	}
+
+	findings, _, err := fetchFindings(*wkspId, filter)
+	if err != nil {
+		return err
+	}
+	findings, suppressed := suppressFindings(filterByStatus(findings, *status), baseline, rules)
+	if grouped {
+		err = printSecretsGroups(groupFindings(findings, by, filterFlags.Top), by, *jsonOutput)
+	} else {
+		err = printFindings(findings, *jsonOutput)
+	}
+	if err != nil {
+		return err
+	}
+	if suppressed > 0 && !*jsonOutput {
+		fmt.Printf("[INF] %d findings suppressed by the baseline or %s\n", suppressed, *ignorePath)
+	}
+	return checkNewFindings(findings, baseline, *fail)
+}
+
+func runSecretsBaseline(args []string) error {
+	fs := newCommandFlagSet("secrets baseline", "secrets baseline [-o secrets.baseline.json]")
+	wkspId := fs.String("wksp", *workspaceFlag, "Workspace ID")
+	output := fs.String("o", "secrets.baseline.json", "Baseline file to write")
+	ignorePath := fs.String("ignore", defaultIgnoreFile, "Suppression rules file; suppressed findings are left out of the baseline")
+	positional, err := parseCommandArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) > 0 {
+		return fmt.Errorf("unexpected arguments: %s", strings.Join(positional, " "))
+	}
+	rules, err := loadIgnoreRules(*ignorePath, *ignorePath != defaultIgnoreFile)
+	if err != nil {
+		return err
+	}
+	if err := requireWorkspace(*wkspId); err != nil {
+		return err
+	}
+
+	findings, _, err := fetchFindings(*wkspId, secretsFilter{})
+	if err != nil {
+		return err
+	}
+	findings, _ = suppressFindings(findings, nil, rules)
+	if err := writeBaseline(*output, findings); err != nil {
+		return err
+	}
+	fmt.Printf("[INF] Wrote %d findings to %s\n", len(findings), *output)
+	return nil
+}
+
+func runSecretsMark(args []string) error {
+	fs := newCommandFlagSet("secrets mark", "secrets mark <status> <finding-id>... [-note text]")
+	wkspId := fs.String("wksp", *workspaceFlag, "Workspace ID")
+	note := fs.String("note", "", "Note to store with the status")
+	positional, err := parseCommandArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) < 2 {
+		fs.Usage()
+		return fmt.Errorf("secrets mark needs a status and at least one finding ID")
+	}
+	status, err := parseTriageStatus(positional[0])
+	if err != nil {
+		return err
+	}
+	if err := requireWorkspace(*wkspId); err != nil {
+		return err
+	}
+
+	findings, store, err := fetchFindings(*wkspId, secretsFilter{})
+	if err != nil {
+		return err
+	}
+	for _, id := range positional[1:] {
+		var matches []secretFinding
+		for _, finding := range findings {
+			if strings.HasPrefix(finding.ID, id) {
+				matches = append(matches, finding)
+			}
+		}
+		switch len(matches) {
+		case 0:
+			return fmt.Errorf("no finding with ID %s", id)
+		case 1:
+			store.mark(matches[0], status, *note)
+			fmt.Printf("[INF] Marked %s (%s: %s) as %s\n", matches[0].ID, matches[0].Module, matches[0].Word, status)
+		default:
+			return fmt.Errorf("finding ID %s is ambiguous, use more characters", id)
+		}
+	}
+	return store.save()
+}
+
+func runSecretsTriage(args []string) error {
+	fs := newCommandFlagSet("secrets triage", "secrets triage [-status new] [-module m] [-domain d] [-since 7d] [-url-match regex]")
+	wkspId := fs.String("wksp", *workspaceFlag, "Workspace ID")
+	status := fs.String("status", statusNew, "Review findings with this triage status (or all)")
+	var filterFlags secretsFilterFlags
+	filterFlags.register(fs)
+	positional, err := parseCommandArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) > 0 {
+		return fmt.Errorf("unexpected arguments: %s", strings.Join(positional, " "))
+	}
+	if *status != "all" {
+		if *status, err = parseTriageStatus(*status); err != nil {
+			return err
+		}
+	}
+	filter, err := filterFlags.filter()
+	if err != nil {
+		return err
+	}
+	if err := requireWorkspace(*wkspId); err != nil {
+		return err
+	}
+
+	findings, store, err := fetchFindings(*wkspId, filter)
+	if err != nil {
+		return err
+	}
+	findings = filterByStatus(findings, *status)
+	if len(findings) == 0 {
+		fmt.Println("No findings to review")
+		return nil
+	}
+
+	reader := bufio.NewReader(os.Stdin)
+	reviewed := 0
+	for i, finding := range findings {
+		fmt.Printf("\n[%d/%d] %s: %s\n", i+1, len(findings), finding.Module, finding.Word)
+		fmt.Printf("  URL: %s\n", finding.URL)
+		fmt.Printf("  jsmon ID: %s  finding ID: %s  status: %s\n", finding.JsmonId, finding.ID, finding.Status)
+		if finding.Note != "" {
+			fmt.Printf("  note: %s\n", finding.Note)
+		}
+		newStatus, quit := readTriageChoice(reader, os.Stdout)
+		if quit {
+			break
+		}
+		if newStatus == "" {
+			continue
+		}
+		note := finding.Note
+		if newStatus != statusNew {
+			fmt.Print("Note (optional): ")
+			line, _ := reader.ReadString('\n')
+			if line = strings.TrimSpace(line); line != "" {
+				note = line
+			}
+		}
+		store.mark(finding, newStatus, note)
+		if err := store.save(); err != nil {
+			return err
+		}
+		reviewed++
+	}
+	fmt.Printf("\n[INF] Reviewed %d of %d findings\n", reviewed, len(findings))
+	return nil
+}
+
+var triageChoices = map[string]string{"c": statusConfirmed, "f": statusFalsePositive, "x": statusFixed, "n": statusNew}
+
+// readTriageChoice prompts until the answer is a valid choice. It returns the
+// chosen status, "" to skip the finding, or quit on q or at the end of input.
+func readTriageChoice(reader *bufio.Reader, w io.Writer) (string, bool) {
+	for {
+		fmt.Fprint(w, "(c)onfirmed, (f)alse-positive, fi(x)ed, (n)ew, (s)kip, (q)uit [s]: ")
+		answer, err := reader.ReadString('\n')
+		answer = strings.ToLower(strings.TrimSpace(answer))
+		if answer == "q" {
+			return "", true
+		}
+		if answer == "s" || answer == "" && err == nil {
+			return "", false
+		}
+		if status, ok := triageChoices[answer]; ok {
+			return status, false
+		}
+		if err != nil {
+			return "", true
+		}
+		fmt.Fprintf(w, "Invalid choice %q, use c, f, x, n, s or q\n", answer)
+	}
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+)
+
+func TestReadTriageChoice(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		status string
+		quit   bool
+		tries  int
+	}{
+		{"confirm", "c\n", statusConfirmed, false, 1},
+		{"upper case", " F \n", statusFalsePositive, false, 1},
+		{"default skip", "\n", "", false, 1},
+		{"explicit skip", "s\n", "", false, 1},
+		{"quit", "q\n", "", true, 1},
+		{"end of input", "", "", true, 1},
+		{"last line without newline", "x", statusFixed, false, 1},
+		{"invalid then valid", "yes\nconfirm\nn\n", statusNew, false, 3},
+		{"invalid at end of input", "maybe", "", true, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out strings.Builder
+			status, quit := readTriageChoice(bufio.NewReader(strings.NewReader(tt.input)), &out)
+			if status != tt.status || quit != tt.quit {
+				t.Errorf("got %q, %v, want %q, %v", status, quit, tt.status, tt.quit)
+			}
+			if tries := strings.Count(out.String(), "[s]: "); tries != tt.tries {
+				t.Errorf("prompted %d times, want %d:\n%s", tries, tt.tries, out.String())
+			}
+		})
+	}
+}
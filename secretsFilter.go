@@ -0,0 +1,239 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// secretsFilter selects scanner results by module, domain, date and URL.
+type secretsFilter struct {
+	Modules  []string
+	Domains  []string
+	Since    time.Time
+	URLMatch *regexp.Regexp
+	// Verify runs the offline checks and drops words scoring below
+	// MinConfidence.
+	Verify        bool
+	MinConfidence float64
+}
+
+// secretsFilterFlags holds the raw filter and grouping flags of the secrets
+// and scan-local commands.
+type secretsFilterFlags struct {
+	Module        string
+	Domain        string
+	Since         string
+	URLMatch      string
+	Group         string
+	Top           int
+	Verify        bool
+	MinConfidence float64
+}
+
+func (f *secretsFilterFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&f.Module, "module", "", "Only show secrets detected by these comma-separated modules")
+	fs.StringVar(&f.Domain, "domain", "", "Only show secrets in JS files on these comma-separated domains (subdomains included)")
+	fs.StringVar(&f.Since, "since", "", "Only show secrets found since a date (2006-01-02) or for a duration (24h, 7d)")
+	fs.StringVar(&f.URLMatch, "url-match", "", "Only show secrets in JS files whose URL matches this regex")
+	fs.StringVar(&f.Group, "group", "", "Summarize the secrets by module or domain")
+	fs.IntVar(&f.Top, "top", 0, "Only show the N largest groups (groups by module unless -group is set)")
+	fs.BoolVar(&f.Verify, "verify", false, "Check secrets offline (token checksums, JWT expiry, entropy, placeholders) and score their confidence")
+	fs.Float64Var(&f.MinConfidence, "min-confidence", 0, "Only show secrets with at least this confidence between 0 and 1 (implies -verify)")
+}
+
+func (f secretsFilterFlags) filter() (secretsFilter, error) {
+	if f.MinConfidence < 0 || f.MinConfidence > 1 {
+		return secretsFilter{}, fmt.Errorf("-min-confidence must be between 0 and 1")
+	}
+	filter := secretsFilter{
+		Modules:       splitWords(f.Module),
+		Verify:        f.Verify || f.MinConfidence > 0,
+		MinConfidence: f.MinConfidence,
+	}
+	for _, domain := range splitWords(f.Domain) {
+		filter.Domains = append(filter.Domains, normalizeHost(domain))
+	}
+	if f.Since != "" {
+		since, err := parseSince(f.Since, time.Now())
+		if err != nil {
+			return filter, err
+		}
+		filter.Since = since
+	}
+	if f.URLMatch != "" {
+		pattern, err := regexp.Compile(f.URLMatch)
+		if err != nil {
+			return filter, fmt.Errorf("invalid -url-match regex: %v", err)
+		}
+		filter.URLMatch = pattern
+	}
+	return filter, nil
+}
+
+// grouping returns the -group key and whether a summary was requested.
+func (f secretsFilterFlags) grouping() (string, bool, error) {
+	switch strings.ToLower(f.Group) {
+	case "":
+		if f.Top > 0 {
+			return "module", true, nil
+		}
+		return "", false, nil
+	case "module", "domain":
+		return strings.ToLower(f.Group), true, nil
+	}
+	return "", false, fmt.Errorf("invalid -group %q, use module or domain", f.Group)
+}
+
+// parseSince parses an absolute date or a duration back from now. Durations
+// may use a "d" suffix for days.
+func parseSince(value string, now time.Time) (time.Time, error) {
+	if t, ok := parseVersionTime(value); ok {
+		return t, nil
+	}
+	if strings.HasSuffix(value, "d") {
+		if days, err := strconv.Atoi(strings.TrimSuffix(value, "d")); err == nil && days >= 0 {
+			return now.Add(-time.Duration(days) * 24 * time.Hour), nil
+		}
+	}
+	if d, err := time.ParseDuration(value); err == nil && d >= 0 {
+		return now.Add(-d), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid -since %q, use a date like 2006-01-02 or a duration like 24h or 7d", value)
+}
+
+func (f secretsFilter) matchesItem(item DataItem) bool {
+	if len(f.Domains) > 0 {
+		host := normalizeHost(item.URL)
+		matched := false
+		for _, domain := range f.Domains {
+			matched = matched || host == domain || strings.HasSuffix(host, "."+domain)
+		}
+		if !matched {
+			return false
+		}
+	}
+	if !f.Since.IsZero() {
+		created, ok := parseVersionTime(item.CreatedAt)
+		if !ok || created.Before(f.Since) {
+			return false
+		}
+	}
+	return f.URLMatch == nil || f.URLMatch.MatchString(item.URL)
+}
+
+func (f secretsFilter) matchesModule(module string) bool {
+	if len(f.Modules) == 0 {
+		return true
+	}
+	for _, m := range f.Modules {
+		if strings.EqualFold(m, module) {
+			return true
+		}
+	}
+	return false
+}
+
+// apply returns the items that match the filter, keeping only the detected
+// words of the selected modules and, when verifying, with enough confidence.
+// Items left without detected words are dropped.
+func (f secretsFilter) apply(items []DataItem) []DataItem {
+	if f.Verify {
+		verifyDataItems(items)
+	}
+	var filtered []DataItem
+	for _, item := range items {
+		if !f.matchesItem(item) {
+			continue
+		}
+		var words []DetectedWord
+		for _, detected := range item.DetectedWords {
+			if !f.matchesModule(detected.Name) {
+				continue
+			}
+			if f.MinConfidence > 0 {
+				detected = detected.withMinConfidence(f.MinConfidence)
+			}
+			if len(detected.Words) > 0 {
+				words = append(words, detected)
+			}
+		}
+		if len(words) == 0 {
+			continue
+		}
+		item.DetectedWords = words
+		filtered = append(filtered, item)
+	}
+	return filtered
+}
+
+type secretsGroup struct {
+	Key      string `json:"key"`
+	Files    int    `json:"files"`
+	Findings int    `json:"findings"`
+}
+
+// groupFindings counts findings and distinct JS files per module or domain,
+// largest groups first. A top above 0 keeps only that many groups.
+func groupFindings(findings []secretFinding, by string, top int) []secretsGroup {
+	groups := map[string]*secretsGroup{}
+	files := map[string]map[string]bool{}
+	for _, finding := range findings {
+		key := finding.Module
+		if by == "domain" {
+			key = normalizeHost(finding.URL)
+		}
+		if groups[key] == nil {
+			groups[key] = &secretsGroup{Key: key}
+			files[key] = map[string]bool{}
+		}
+		groups[key].Findings++
+		if !files[key][finding.URL] {
+			files[key][finding.URL] = true
+			groups[key].Files++
+		}
+	}
+
+	result := make([]secretsGroup, 0, len(groups))
+	for _, group := range groups {
+		result = append(result, *group)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Findings != result[j].Findings {
+			return result[i].Findings > result[j].Findings
+		}
+		return result[i].Key < result[j].Key
+	})
+	if top > 0 && len(result) > top {
+		result = result[:top]
+	}
+	return result
+}
+
+func printSecretsGroups(groups []secretsGroup, by string, jsonOutput bool) error {
+	if jsonOutput {
+		jsonData, err := json.MarshalIndent(groups, "", "  ")
+		if err != nil {
+			return fmt.Errorf("error formatting JSON: %v", err)
+		}
+		fmt.Println(string(jsonData))
+		return nil
+	}
+	if len(groups) == 0 {
+		fmt.Println("No findings")
+		return nil
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "%s\tFILES\tFINDINGS\n", strings.ToUpper(by))
+	for _, group := range groups {
+		fmt.Fprintf(w, "%s\t%d\t%d\n", group.Key, group.Files, group.Findings)
+	}
+	return w.Flush()
+}
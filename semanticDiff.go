@@ -0,0 +1,262 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var (
+	urlPattern        = regexp.MustCompile(`(?i)\b(?:https?|wss?)://[^\s"'<>\x60\\]+`)
+	apiPathPattern    = regexp.MustCompile(`^/[\w\-.~%{}:@$]+(?:/[\w\-.~%{}:@$]*)*(?:\?[^\s]*)?$`)
+	templateSubst     = regexp.MustCompile(`\$\{[^}]*\}`)
+	staticAssetSuffix = regexp.MustCompile(`(?i)\.(?:png|jpe?g|gif|svg|ico|webp|css|woff2?|ttf|eot|map)$`)
+)
+
+// Semantic diff categories, in report order.
+const (
+	semanticURLs    = "urls"
+	semanticAPIs    = "api-paths"
+	semanticSecrets = "secrets"
+	semanticStrings = "strings"
+)
+
+var semanticCategories = []string{semanticSecrets, semanticURLs, semanticAPIs, semanticStrings}
+
+// jsFeatures maps a category to its values and the line of their first
+// occurrence in the beautified source.
+type jsFeatures map[string]map[string]int
+
+// SemanticChange is a value that appeared in or disappeared from a JS file.
+type SemanticChange struct {
+	Category string `json:"category"`
+	Value    string `json:"value"`
+	Added    bool   `json:"added"`
+	Line     int    `json:"line"`
+}
+
+// extractJSFeatures beautifies src, tokenizes it and collects the string
+// literals, URLs, API paths and secrets it contains. Identifiers are ignored,
+// so renamed variables in a re-minified bundle do not show up as changes.
+// Secrets are the matches of rules.
+func extractJSFeatures(src string, rules []secretRule) jsFeatures {
+	features := jsFeatures{}
+	for _, category := range semanticCategories {
+		features[category] = map[string]int{}
+	}
+	add := func(category, value string, line int) {
+		if _, seen := features[category][value]; !seen {
+			features[category][value] = line
+		}
+	}
+
+	for _, token := range tokenizeJS(beautifyJS(src)) {
+		if token.Kind != jsString && token.Kind != jsTemplate {
+			continue
+		}
+		text := token.Text
+		if token.Kind == jsTemplate {
+			text = templateSubst.ReplaceAllString(text, "{}")
+		}
+
+		classified := false
+		for i := range rules {
+			for _, match := range rules[i].find("", text) {
+				add(semanticSecrets, rules[i].ID+": "+match, token.Line)
+				classified = true
+			}
+		}
+		for _, match := range urlPattern.FindAllString(text, -1) {
+			add(semanticURLs, match, token.Line)
+			classified = true
+		}
+		if isAPIPath(text) {
+			add(semanticAPIs, text, token.Line)
+			classified = true
+		} else if i := strings.Index(text, "/"); i > 0 && token.Kind == jsTemplate && isAPIPath(text[i:]) {
+			add(semanticAPIs, text[i:], token.Line)
+			classified = true
+		}
+		if !classified && len(strings.TrimSpace(text)) >= 3 {
+			add(semanticStrings, text, token.Line)
+		}
+	}
+	return features
+}
+
+// isAPIPath reports whether s looks like a server path rather than a static
+// asset or a plain word.
+func isAPIPath(s string) bool {
+	return len(s) > 1 && apiPathPattern.MatchString(s) && !staticAssetSuffix.MatchString(s) &&
+		!strings.HasPrefix(s, "//")
+}
+
+// semanticDiff compares the features of two JS versions. Removed values carry
+// their line in the old version, added values their line in the new one.
+func semanticDiff(oldSrc, newSrc string, rules []secretRule) []SemanticChange {
+	oldFeatures := extractJSFeatures(oldSrc, rules)
+	newFeatures := extractJSFeatures(newSrc, rules)
+
+	var changes []SemanticChange
+	for _, category := range semanticCategories {
+		for value, line := range newFeatures[category] {
+			if _, exists := oldFeatures[category][value]; !exists {
+				changes = append(changes, SemanticChange{Category: category, Value: value, Added: true, Line: line})
+			}
+		}
+		for value, line := range oldFeatures[category] {
+			if _, exists := newFeatures[category][value]; !exists {
+				changes = append(changes, SemanticChange{Category: category, Value: value, Added: false, Line: line})
+			}
+		}
+	}
+
+	order := map[string]int{}
+	for i, category := range semanticCategories {
+		order[category] = i
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		a, b := changes[i], changes[j]
+		if a.Category != b.Category {
+			return order[a.Category] < order[b.Category]
+		}
+		if a.Added != b.Added {
+			return a.Added
+		}
+		return a.Value < b.Value
+	})
+	return changes
+}
+
+func writeSemanticDiff(w io.Writer, changes []SemanticChange, jsonOutput bool) error {
+	if jsonOutput {
+		if changes == nil {
+			changes = []SemanticChange{}
+		}
+		jsonData, err := json.MarshalIndent(changes, "", "  ")
+		if err != nil {
+			return fmt.Errorf("error formatting JSON: %v", err)
+		}
+		fmt.Fprintln(w, string(jsonData))
+		return nil
+	}
+
+	if len(changes) == 0 {
+		fmt.Fprintln(w, "[INF] No semantic changes found.")
+		return nil
+	}
+	titles := map[string]string{
+		semanticSecrets: "Secrets",
+		semanticURLs:    "URLs",
+		semanticAPIs:    "API paths",
+		semanticStrings: "String literals",
+	}
+	category := ""
+	for _, change := range changes {
+		if change.Category != category {
+			category = change.Category
+			fmt.Fprintf(w, "\n%s:\n", titles[category])
+		}
+		sign, version := "+", "new"
+		if !change.Added {
+			sign, version = "-", "old"
+		}
+		fmt.Fprintf(w, "%s %s (%s:%d)\n", sign, change.Value, version, change.Line)
+	}
+	return nil
+}
+
+// isLocalJSRef reports whether ref is a URL or an existing file rather than a
+// jsmon ID.
+func isLocalJSRef(ref string) bool {
+	if isHTTPRef(ref) {
+		return true
+	}
+	_, err := os.Stat(ref)
+	return err == nil
+}
+
+// versionSources rebuilds both stored versions from the compare endpoint's
+// line diff, which includes the unchanged chunks.
+func versionSources(items []DiffItem) (string, string) {
+	var oldSrc, newSrc strings.Builder
+	for _, item := range items {
+		if !item.Added {
+			oldSrc.WriteString(item.Value)
+		}
+		if !item.Removed {
+			newSrc.WriteString(item.Value)
+		}
+	}
+	return oldSrc.String(), newSrc.String()
+}
+
+// readSemanticSources returns the two sources to compare: local files or URLs
+// are read through fetcher, and two jsmon IDs are fetched through the compare
+// endpoint that diff uses.
+func readSemanticSources(fetcher jsFetcher, oldRef, newRef, wkspId string) (string, string, error) {
+	oldLocal, newLocal := isLocalJSRef(oldRef), isLocalJSRef(newRef)
+	if !oldLocal && !newLocal {
+		if err := requireWorkspace(wkspId); err != nil {
+			return "", "", err
+		}
+		items, err := compareEndpoint(oldRef, newRef, wkspId)
+		if err != nil {
+			return "", "", err
+		}
+		oldSrc, newSrc := versionSources(items)
+		return oldSrc, newSrc, nil
+	}
+	if !oldLocal || !newLocal {
+		ref := oldRef
+		if oldLocal {
+			ref = newRef
+		}
+		return "", "", fmt.Errorf("%s is not a file or URL; compare two jsmon IDs or two files or URLs", ref)
+	}
+
+	oldResource, err := fetcher.fetch(oldRef)
+	if err != nil {
+		return "", "", err
+	}
+	newResource, err := fetcher.fetch(newRef)
+	if err != nil {
+		return "", "", err
+	}
+	return string(oldResource.Body), string(newResource.Body), nil
+}
+
+func runSemanticDiff(oldRef, newRef, wkspId string, headerList []string, jsonOutput bool, output string) error {
+	oldSrc, newSrc, err := readSemanticSources(newJSFetcher(headerList), oldRef, newRef, wkspId)
+	if err != nil {
+		return err
+	}
+
+	rules, err := defaultSecretRules()
+	if err != nil {
+		return err
+	}
+	changes := semanticDiff(oldSrc, newSrc, rules)
+	if len(changes) == 0 && output != "" {
+		fmt.Println("[INF] No semantic changes found.")
+		return nil
+	}
+
+	if output == "" {
+		return writeSemanticDiff(os.Stdout, changes, jsonOutput)
+	}
+	var buf bytes.Buffer
+	if err := writeSemanticDiff(&buf, changes, jsonOutput); err != nil {
+		return err
+	}
+	if err := os.WriteFile(output, buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("error writing %s: %v", output, err)
+	}
+	fmt.Printf("[INF] Semantic diff written to %s\n", output)
+	return nil
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestVersionSources(t *testing.T) {
+	items := []DiffItem{
+		{Value: "const a = 1;\n"},
+		{Removed: true, Value: "fetch('/api/v1');\n"},
+		{Added: true, Value: "fetch('/api/v2');\n"},
+		{Value: "done();"},
+	}
+	oldSrc, newSrc := versionSources(items)
+	if want := "const a = 1;\nfetch('/api/v1');\ndone();"; oldSrc != want {
+		t.Errorf("got old source %q, want %q", oldSrc, want)
+	}
+	if want := "const a = 1;\nfetch('/api/v2');\ndone();"; newSrc != want {
+		t.Errorf("got new source %q, want %q", newSrc, want)
+	}
+}
+
+func TestReadSemanticSourcesMixedRefs(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "old.js")
+	if err := os.WriteFile(path, []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	_, _, err := readSemanticSources(newJSFetcher(nil), path, "6f1c2a", "wksp")
+	if err == nil || !strings.Contains(err.Error(), "6f1c2a is not a file or URL") {
+		t.Errorf("got error %v, want a mixed reference error", err)
+	}
+}
+
+func TestSemanticDiffIgnoresMinifierNoise(t *testing.T) {
+	oldSrc := `function a(b){var c="https://api.example.com/v1/users";return fetch(c+"/"+b,{headers:{"X-Client":"web"}}).then(function(d){return d.json()})}`
+	newSrc := `
+function loadUser(userId) {
+    var baseUrl = 'https://api.example.com/v1/users';
+    return fetch(baseUrl + '/' + userId, {
+        headers: { 'X-Client': 'web' }
+    }).then(function (response) {
+        return response.json();
+    });
+}
+`
+	rules, err := defaultSecretRules()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if changes := semanticDiff(oldSrc, newSrc, rules); len(changes) != 0 {
+		t.Errorf("got %+v for renamed identifiers and whitespace, want no changes", changes)
+	}
+
+	changed := strings.Replace(newSrc, "/v1/users", "/v2/users", 1)
+	changes := semanticDiff(oldSrc, changed, rules)
+	var urls []string
+	for _, change := range changes {
+		if change.Category == semanticURLs {
+			urls = append(urls, change.Value)
+		}
+	}
+	if want := []string{"https://api.example.com/v2/users", "https://api.example.com/v1/users"}; strings.Join(urls, " ") != strings.Join(want, " ") {
+		t.Errorf("got URL changes %q, want %q", urls, want)
+	}
+}
+
+func TestReadSemanticSourcesHeaders(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer t" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.Write([]byte("fetch('" + r.URL.Path + "')"))
+	}))
+	defer server.Close()
+
+	oldSrc, newSrc, err := readSemanticSources(newJSFetcher([]string{"Authorization: Bearer t"}), server.URL+"/old.js", server.URL+"/new.js", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if oldSrc != "fetch('/old.js')" || newSrc != "fetch('/new.js')" {
+		t.Errorf("got %q and %q", oldSrc, newSrc)
+	}
+	if _, _, err := readSemanticSources(newJSFetcher(nil), server.URL+"/old.js", server.URL+"/new.js", ""); err == nil || !strings.Contains(err.Error(), "403") {
+		t.Errorf("got error %v without the header", err)
+	}
+}
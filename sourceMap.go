@@ -0,0 +1,353 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// sourceMappingURLPattern matches //# sourceMappingURL=... and the older //@
+// and /*# ... */ forms. The last match in a file wins.
+var sourceMappingURLPattern = regexp.MustCompile(`(?m)(?://|/\*)[#@]\s*sourceMappingURL=([^\s*'"]+)`)
+
+// sourceMap is a version 3 source map. Index maps hold their parts in
+// Sections.
+type sourceMap struct {
+	Version        int                `json:"version"`
+	File           string             `json:"file,omitempty"`
+	SourceRoot     string             `json:"sourceRoot,omitempty"`
+	Sources        []string           `json:"sources"`
+	SourcesContent []*string          `json:"sourcesContent,omitempty"`
+	Mappings       string             `json:"mappings"`
+	Sections       []sourceMapSection `json:"sections,omitempty"`
+}
+
+type sourceMapSection struct {
+	URL string     `json:"url,omitempty"`
+	Map *sourceMap `json:"map,omitempty"`
+}
+
+// sourceMapSource is one original file of a source map.
+type sourceMapSource struct {
+	Name    string
+	Content string
+	// Missing is set when the map lists the source without its content.
+	Missing bool
+}
+
+// findSourceMapRef returns the source map reference of a JS file: the
+// SourceMap or X-SourceMap response header, else the last sourceMappingURL
+// comment.
+func findSourceMapRef(resource jsResource) string {
+	for _, header := range []string{"SourceMap", "X-SourceMap"} {
+		if ref := resource.Header.Get(header); ref != "" {
+			return strings.TrimSpace(ref)
+		}
+	}
+	matches := sourceMappingURLPattern.FindAllSubmatch(resource.Body, -1)
+	if len(matches) == 0 {
+		return ""
+	}
+	return string(matches[len(matches)-1][1])
+}
+
+// decodeDataURI returns the payload of a base64 or percent-encoded data URI.
+func decodeDataURI(uri string) ([]byte, error) {
+	i := strings.Index(uri, ",")
+	if !strings.HasPrefix(uri, "data:") || i < 0 {
+		return nil, fmt.Errorf("invalid data URI")
+	}
+	meta, payload := uri[len("data:"):i], uri[i+1:]
+	if strings.HasSuffix(meta, ";base64") {
+		data, err := base64.StdEncoding.DecodeString(payload)
+		if err != nil {
+			// Some bundlers emit unpadded or URL-safe base64.
+			data, err = base64.RawURLEncoding.DecodeString(strings.TrimRight(strings.NewReplacer("+", "-", "/", "_").Replace(payload), "="))
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid base64 in data URI: %v", err)
+		}
+		return data, nil
+	}
+	data, err := url.PathUnescape(payload)
+	if err != nil {
+		return nil, fmt.Errorf("invalid data URI: %v", err)
+	}
+	return []byte(data), nil
+}
+
+func parseSourceMap(data []byte) (*sourceMap, error) {
+	// Maps may start with an XSSI guard line such as )]}'.
+	if strings.HasPrefix(string(data), ")]}") {
+		if i := strings.IndexByte(string(data), '\n'); i >= 0 {
+			data = data[i+1:]
+		}
+	}
+	var m sourceMap
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("error parsing source map: %v", err)
+	}
+	if m.Version != 3 {
+		return nil, fmt.Errorf("unsupported source map version %d", m.Version)
+	}
+	return &m, nil
+}
+
+// looksLikeSourceMap reports whether data is a source map rather than JS.
+func looksLikeSourceMap(data []byte) bool {
+	text := strings.TrimSpace(strings.TrimPrefix(string(data), ")]}'"))
+	return strings.HasPrefix(text, "{") && strings.Contains(text, `"version"`) &&
+		(strings.Contains(text, `"mappings"`) || strings.Contains(text, `"sections"`))
+}
+
+// sources lists the original files of the map with sourceRoot applied,
+// including those of index map sections.
+func (m *sourceMap) sources() []sourceMapSource {
+	var sources []sourceMapSource
+	for i, name := range m.Sources {
+		if m.SourceRoot != "" && !strings.Contains(name, "://") && !strings.HasPrefix(name, "/") {
+			name = strings.TrimSuffix(m.SourceRoot, "/") + "/" + name
+		}
+		source := sourceMapSource{Name: name, Missing: true}
+		if i < len(m.SourcesContent) && m.SourcesContent[i] != nil {
+			source.Content, source.Missing = *m.SourcesContent[i], false
+		}
+		sources = append(sources, source)
+	}
+	for _, section := range m.Sections {
+		if section.Map != nil {
+			sources = append(sources, section.Map.sources()...)
+		}
+	}
+	return sources
+}
+
+// sourceMapSchemePattern matches the pseudo-URL prefixes bundlers put on
+// source names, such as webpack://.
+var sourceMapSchemePattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9+.\-]*://`)
+
+// safeSourcePath turns a source name such as "webpack:///./src/app.js?abc"
+// into a relative slash path with no "..", absolute or drive components, so
+// writing it under the output directory cannot escape it.
+func safeSourcePath(name string) string {
+	if i := strings.IndexAny(name, "?#"); i >= 0 {
+		name = name[:i]
+	}
+	if scheme := sourceMapSchemePattern.FindString(name); scheme != "" {
+		name = name[len(scheme):]
+		if scheme == "http://" || scheme == "https://" {
+			// Keep the host as the first directory.
+			name = strings.Replace(name, ":", "_", 1)
+		}
+	}
+	name = strings.ReplaceAll(name, `\`, "/")
+	var parts []string
+	for _, part := range strings.Split(name, "/") {
+		if len(part) == 2 && part[1] == ':' {
+			continue
+		}
+		switch part {
+		case "", ".", "..", "~":
+			continue
+		}
+		parts = append(parts, part)
+	}
+	return path.Join(parts...)
+}
+
+// writeSourceTree writes the sources that have content under dir and returns
+// the paths written. Sources that map to the same path get a numeric suffix.
+func writeSourceTree(dir string, sources []sourceMapSource) ([]string, error) {
+	root, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, fmt.Errorf("error resolving %s: %v", dir, err)
+	}
+	used := map[string]bool{}
+	var written []string
+	for i, source := range sources {
+		if source.Missing {
+			continue
+		}
+		rel := safeSourcePath(source.Name)
+		if rel == "" {
+			rel = fmt.Sprintf("source-%d.js", i)
+		}
+		candidate := rel
+		for n := 2; used[candidate]; n++ {
+			ext := path.Ext(rel)
+			candidate = fmt.Sprintf("%s~%d%s", strings.TrimSuffix(rel, ext), n, ext)
+		}
+		used[candidate] = true
+
+		target := filepath.Join(root, filepath.FromSlash(candidate))
+		if r, err := filepath.Rel(root, target); err != nil || r == ".." || strings.HasPrefix(r, ".."+string(filepath.Separator)) {
+			return written, fmt.Errorf("refusing to write %s outside %s", source.Name, dir)
+		}
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return written, fmt.Errorf("error creating directory for %s: %v", candidate, err)
+		}
+		if err := os.WriteFile(target, []byte(source.Content), 0644); err != nil {
+			return written, fmt.Errorf("error writing %s: %v", target, err)
+		}
+		written = append(written, target)
+	}
+	return written, nil
+}
+
+// isSourceScript reports whether a reconstructed source is worth analyzing.
+// Maps often hold TypeScript, which the local analysis reads like JS.
+func isSourceScript(file string) bool {
+	ext := strings.ToLower(filepath.Ext(file))
+	return jsFileExtensions[ext] || ext == ".ts" || ext == ".tsx"
+}
+
+// sourceMapResult describes the map found for one input.
+type sourceMapResult struct {
+	Input   string
+	MapRef  string
+	Map     *sourceMap
+	Guessed bool
+}
+
+// loadSourceMap finds and parses the source map of a JS file, or parses ref
+// itself if it is a map. With guess set, <ref>.map is tried when the file
+// does not reference a map.
+func loadSourceMap(fetcher jsFetcher, ref string, guess bool) (sourceMapResult, error) {
+	result := sourceMapResult{Input: ref}
+	resource, err := fetcher.fetch(ref)
+	if err != nil {
+		return result, err
+	}
+	if looksLikeSourceMap(resource.Body) {
+		result.MapRef = ref
+		result.Map, err = parseSourceMap(resource.Body)
+		return result, err
+	}
+
+	mapRef := findSourceMapRef(resource)
+	if mapRef == "" {
+		if !guess {
+			return result, fmt.Errorf("no sourceMappingURL found in %s", ref)
+		}
+		mapRef, result.Guessed = path.Base(strings.SplitN(ref, "?", 2)[0])+".map", true
+	}
+	if strings.HasPrefix(mapRef, "data:") {
+		data, err := decodeDataURI(mapRef)
+		if err != nil {
+			return result, err
+		}
+		result.MapRef = "inline map"
+		result.Map, err = parseSourceMap(data)
+		return result, err
+	}
+	if result.MapRef, err = resolveJSRef(ref, mapRef); err != nil {
+		return result, err
+	}
+	mapResource, err := fetcher.fetch(result.MapRef)
+	if err != nil {
+		return result, err
+	}
+	result.Map, err = parseSourceMap(mapResource.Body)
+	return result, err
+}
+
+// sourceMapOutputDir returns the directory sources of input are written to
+// when several inputs share one output directory.
+func sourceMapOutputDir(base, input string, multiple bool) string {
+	if !multiple {
+		return base
+	}
+	name := safeSourcePath(input)
+	if name == "" {
+		name = "input"
+	}
+	return filepath.Join(base, filepath.FromSlash(name))
+}
+
+func runSourceMapCommand(args []string) error {
+	fs := newCommandFlagSet("sourcemap", "sourcemap <file.js|url|file.map>... [-o dir] [-guess] [-upload-map -wksp <id>] [-analyze]")
+	outDir := fs.String("o", "sources", "Directory to reconstruct the original sources into")
+	guess := fs.Bool("guess", false, "Try <file>.map when a JS file has no sourceMappingURL")
+	uploadMap := fs.Bool("upload-map", false, "Submit the URL of each remote source map for scanning (the sources themselves are not uploaded)")
+	analyze := fs.Bool("analyze", false, "Run the local analysis on the reconstructed sources")
+	wkspId := fs.String("wksp", *workspaceFlag, "Workspace ID for -upload-map")
+	var headerFlags stringSliceFlag
+	fs.Var(&headerFlags, "H", "Header for fetching JS and maps in the format 'Key: Value' (can be used multiple times)")
+	positional, err := parseCommandArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) == 0 {
+		fs.Usage()
+		return fmt.Errorf("sourcemap needs a JS file, URL or source map")
+	}
+	headerList := append(append([]string{}, headers...), headerFlags...)
+	if *uploadMap {
+		if err := ensureAPIKey(); err != nil {
+			return err
+		}
+		if err := requireWorkspace(*wkspId); err != nil {
+			return err
+		}
+	}
+
+	fetcher := newJSFetcher(headerList)
+	var scripts []string
+	failed := 0
+	for _, input := range positional {
+		result, err := loadSourceMap(fetcher, input, *guess)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "[ERR] %s: %v\n", input, err)
+			failed++
+			continue
+		}
+		sources := result.Map.sources()
+		dir := sourceMapOutputDir(*outDir, input, len(positional) > 1)
+		written, err := writeSourceTree(dir, sources)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "[ERR] %s: %v\n", input, err)
+			failed++
+			continue
+		}
+		mapRef := result.MapRef
+		if result.Guessed {
+			mapRef += " (guessed)"
+		}
+		fmt.Printf("[INF] %s: %s, wrote %d of %d sources to %s\n", input, mapRef, len(written), len(sources), dir)
+		if missing := len(sources) - len(written); missing > 0 {
+			fmt.Printf("[WRN] %d sources have no content in the map\n", missing)
+		}
+		for _, file := range written {
+			if isSourceScript(file) {
+				scripts = append(scripts, file)
+			}
+		}
+		if *uploadMap {
+			if !isHTTPRef(result.MapRef) {
+				fmt.Fprintf(os.Stderr, "[WRN] %s: %s is not a URL, not uploaded\n", input, result.MapRef)
+			} else if err := uploadUrlEndpoint(result.MapRef, headerList, *wkspId); err != nil {
+				return fmt.Errorf("error uploading %s: %v", result.MapRef, err)
+			}
+		}
+	}
+
+	if *analyze && len(scripts) > 0 {
+		results, err := analyzeLocalFiles(scripts)
+		if err != nil {
+			return err
+		}
+		if err := printIntelligenceResults(results, nil); err != nil {
+			return err
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d inputs failed", failed, len(positional))
+	}
+	return nil
+}
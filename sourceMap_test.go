@@ -0,0 +1,239 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// stubJSFetcher serves JS files and maps from memory.
+type stubJSFetcher map[string]jsResource
+
+func (f stubJSFetcher) fetch(ref string) (jsResource, error) {
+	resource, ok := f[ref]
+	if !ok {
+		return jsResource{}, fmt.Errorf("error fetching %s: status code 404", ref)
+	}
+	resource.Ref = ref
+	return resource, nil
+}
+
+func TestFindSourceMapRef(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   string
+		header http.Header
+		want   string
+	}{
+		{"hash comment", "var a=1;\n//# sourceMappingURL=main.js.map\n", nil, "main.js.map"},
+		{"at comment", "var a=1;\n//@ sourceMappingURL=old.js.map", nil, "old.js.map"},
+		{"block comment", "body{}\n/*# sourceMappingURL=style.css.map */", nil, "style.css.map"},
+		{"last match wins", "var s='//# sourceMappingURL=fake.map';\n//# sourceMappingURL=real.map\n", nil, "real.map"},
+		{"header", "//# sourceMappingURL=comment.map", http.Header{"Sourcemap": {" /maps/main.map "}}, "/maps/main.map"},
+		{"x header", "var a=1;", http.Header{"X-Sourcemap": {"x.map"}}, "x.map"},
+		{"none", "var a=1;", nil, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := findSourceMapRef(jsResource{Body: []byte(tt.body), Header: tt.header})
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeDataURI(t *testing.T) {
+	tests := []struct {
+		name string
+		uri  string
+		want string
+	}{
+		{"padded", "data:application/json;base64,eyJhIjoxfQ==", `{"a":1}`},
+		{"unpadded", "data:application/json;base64,eyJhIjoxfQ", `{"a":1}`},
+		{"charset", "data:application/json;charset=utf-8;base64,eyJhIjoxfQ==", `{"a":1}`},
+		{"url safe", "data:application/json;base64,Pz8-Pz8_", "??>???"},
+		{"percent encoded", "data:application/json,%7B%22a%22%3A1%7D", `{"a":1}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decodeDataURI(tt.uri)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	for _, uri := range []string{"data:application/json;base64", "text/plain,abc", "data:;base64,!!!"} {
+		if _, err := decodeDataURI(uri); err == nil {
+			t.Errorf("%q: got no error", uri)
+		}
+	}
+}
+
+func TestParseSourceMap(t *testing.T) {
+	m, err := parseSourceMap([]byte(")]}'\n" + `{"version":3,"sourceRoot":"src/","sources":["a.js","webpack:///b.js"],"sourcesContent":["A",null],"mappings":""}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []sourceMapSource{
+		{Name: "src/a.js", Content: "A"},
+		{Name: "webpack:///b.js", Missing: true},
+	}
+	if got := m.sources(); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+
+	index := `{"version":3,"sections":[
+		{"offset":{"line":0,"column":0},"map":{"version":3,"sources":["one.js"],"sourcesContent":["1"],"mappings":"AAAA"}},
+		{"offset":{"line":10,"column":0},"map":{"version":3,"sources":["two.js"],"sourcesContent":["2"],"mappings":"AAAA"}}]}`
+	if !looksLikeSourceMap([]byte(index)) {
+		t.Error("index map not recognized as a source map")
+	}
+	m, err = parseSourceMap([]byte(index))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = []sourceMapSource{{Name: "one.js", Content: "1"}, {Name: "two.js", Content: "2"}}
+	if got := m.sources(); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+
+	for _, data := range []string{`{"version":2,"mappings":""}`, "var a = 1;"} {
+		if _, err := parseSourceMap([]byte(data)); err == nil {
+			t.Errorf("%q: got no error", data)
+		}
+	}
+}
+
+func TestSafeSourcePath(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"webpack:///./src/app.js?abc", "src/app.js"},
+		{"webpack:///../../etc/passwd", "etc/passwd"},
+		{"webpack://app/./node_modules/lib/index.js#L1", "app/node_modules/lib/index.js"},
+		{"../../../etc/passwd", "etc/passwd"},
+		{"/etc/passwd", "etc/passwd"},
+		{`C:\Users\dev\app.js`, "Users/dev/app.js"},
+		{"file:///C:/src/app.ts", "src/app.ts"},
+		{"~/secrets.js", "secrets.js"},
+		{"https://cdn.example.com:8443/lib.js?v=2", "cdn.example.com_8443/lib.js"},
+		{"..", ""},
+	}
+	for _, tt := range tests {
+		if got := safeSourcePath(tt.name); got != tt.want {
+			t.Errorf("safeSourcePath(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestWriteSourceTree(t *testing.T) {
+	dir := t.TempDir()
+	sources := []sourceMapSource{
+		{Name: "webpack:///../../etc/passwd", Content: "root"},
+		{Name: `C:\app\main.js`, Content: "main"},
+		{Name: "~/.ssh/config", Content: "ssh"},
+		{Name: "webpack:///./app/main.js?v=1", Content: "main again"},
+		{Name: "webpack:///./app/main.js?v=2", Content: "main third"},
+		{Name: "missing.js", Missing: true},
+		{Name: "..", Content: "unnamed"},
+	}
+	written, err := writeSourceTree(dir, sources)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := map[string]string{}
+	err = filepath.Walk(dir, func(p string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(dir, p)
+		got[filepath.ToSlash(rel)] = string(data)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"etc/passwd":    "root",
+		"app/main.js":   "main",
+		".ssh/config":   "ssh",
+		"app/main~2.js": "main again",
+		"app/main~3.js": "main third",
+		"source-6.js":   "unnamed",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if len(written) != len(want) {
+		t.Errorf("got %d written paths, want %d", len(written), len(want))
+	}
+	for _, p := range written {
+		if !strings.HasPrefix(p, dir) {
+			t.Errorf("wrote %s outside %s", p, dir)
+		}
+	}
+}
+
+func TestLoadSourceMapGuess(t *testing.T) {
+	mapBody := []byte(`{"version":3,"sources":["src/a.js"],"sourcesContent":["A"],"mappings":""}`)
+	fetcher := stubJSFetcher{
+		"https://x.com/static/main.js?v=1": {Body: []byte("var a=1;")},
+		"https://x.com/static/main.js.map": {Body: mapBody},
+		"https://x.com/static/other.js":    {Body: []byte("var b=2;")},
+		"https://x.com/static/inline.js":   {Body: []byte("var c=3;\n//# sourceMappingURL=data:application/json;base64,eyJ2ZXJzaW9uIjozLCJzb3VyY2VzIjpbXSwibWFwcGluZ3MiOiIifQ==")},
+		"https://x.com/static/direct.map":  {Body: mapBody},
+	}
+
+	result, err := loadSourceMap(fetcher, "https://x.com/static/main.js?v=1", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Guessed || result.MapRef != "https://x.com/static/main.js.map" || len(result.Map.sources()) != 1 {
+		t.Errorf("got %+v", result)
+	}
+
+	if _, err := loadSourceMap(fetcher, "https://x.com/static/main.js?v=1", false); err == nil || !strings.Contains(err.Error(), "no sourceMappingURL") {
+		t.Errorf("got error %v without -guess", err)
+	}
+	if _, err := loadSourceMap(fetcher, "https://x.com/static/other.js", true); err == nil || !strings.Contains(err.Error(), "other.js.map") {
+		t.Errorf("got error %v for a missing guessed map", err)
+	}
+
+	result, err = loadSourceMap(fetcher, "https://x.com/static/inline.js", true)
+	if err != nil || result.Guessed || result.MapRef != "inline map" {
+		t.Errorf("got %+v, %v for an inline map", result, err)
+	}
+	result, err = loadSourceMap(fetcher, "https://x.com/static/direct.map", false)
+	if err != nil || result.MapRef != "https://x.com/static/direct.map" {
+		t.Errorf("got %+v, %v for a map input", result, err)
+	}
+}
+
+func TestIsSourceScript(t *testing.T) {
+	var got []string
+	for _, file := range []string{"a.js", "b.TS", "c.tsx", "d.css", "e.vue", "f.mjs"} {
+		if isSourceScript(file) {
+			got = append(got, file)
+		}
+	}
+	sort.Strings(got)
+	if want := []string{"a.js", "b.TS", "c.tsx", "f.mjs"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"strings"
+)
+
+// levenshtein returns the edit distance between a and b.
+func levenshtein(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = prev[j] + 1
+			if curr[j-1]+1 < curr[j] {
+				curr[j] = curr[j-1] + 1
+			}
+			if prev[j-1]+cost < curr[j] {
+				curr[j] = prev[j-1] + cost
+			}
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
+
+// suggest returns the candidate closest to input, or "" when none is close
+// enough to be a likely typo.
+func suggest(input string, candidates []string) string {
+	input = strings.ToLower(input)
+	best := ""
+	bestDistance := len(input)/3 + 2
+	for _, candidate := range candidates {
+		lower := strings.ToLower(candidate)
+		distance := levenshtein(input, lower)
+		if strings.HasPrefix(lower, input) && len(input) >= 3 {
+			distance = 1
+		}
+		if distance < bestDistance || distance == bestDistance && best != "" && candidate < best {
+			best = candidate
+			bestDistance = distance
+		}
+	}
+	return best
+}
+
+// didYouMean formats a suggestion as a message suffix.
+func didYouMean(input string, candidates []string) string {
+	if s := suggest(input, candidates); s != "" {
+		return ` (did you mean "` + s + `"?)`
+	}
+	return ""
+}
@@ -0,0 +1,53 @@
+package main
+
+import "testing"
+
+func TestLevenshtein(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"", "abc", 3},
+		{"abc", "abc", 0},
+		{"apsi", "apis", 2},
+		{"kitten", "sitting", 3},
+		{"field", "feild", 2},
+	}
+	for _, tt := range tests {
+		if got := levenshtein(tt.a, tt.b); got != tt.want {
+			t.Errorf("levenshtein(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestSuggest(t *testing.T) {
+	candidates := []string{"apis", "urls", "emails", "domains", "domains-status", "node-modules"}
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"apsi", "apis"},
+		{"EMAILS", "emails"},
+		{"email", "emails"},
+		{"domain", "domains"},
+		{"node", "node-modules"},
+		{"url", "urls"},
+		{"xyzzy", ""},
+		{"a", ""},
+	}
+	for _, tt := range tests {
+		if got := suggest(tt.input, candidates); got != tt.want {
+			t.Errorf("suggest(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestDidYouMean(t *testing.T) {
+	if got, want := didYouMean("emals", []string{"emails", "guids"}), ` (did you mean "emails"?)`; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if got := didYouMean("zzzzzz", []string{"emails", "guids"}); got != "" {
+		t.Errorf("got %q, want no suggestion", got)
+	}
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+const triageFile = "triage.json"
+
+// Triage statuses. A finding without a stored status is new.
+const (
+	statusNew           = "new"
+	statusConfirmed     = "confirmed"
+	statusFalsePositive = "false-positive"
+	statusFixed         = "fixed"
+)
+
+var triageStatuses = []string{statusNew, statusConfirmed, statusFalsePositive, statusFixed}
+
+// secretFinding is one detected word in one JS file.
+type secretFinding struct {
+	ID         string  `json:"id"`
+	JsmonId    string  `json:"jsmonId"`
+	URL        string  `json:"url"`
+	Module     string  `json:"module"`
+	Word       string  `json:"word"`
+	CreatedAt  string  `json:"createdAt,omitempty"`
+	Status     string  `json:"status"`
+	Note       string  `json:"note,omitempty"`
+	Verdict    string  `json:"verdict,omitempty"`
+	Confidence float64 `json:"confidence,omitempty"`
+}
+
+// findingID derives a short stable ID from the jsmon ID, module and word.
+func findingID(jsmonId, module, word string) string {
+	sum := sha256.Sum256([]byte(jsmonId + "\x00" + module + "\x00" + word))
+	return hex.EncodeToString(sum[:])[:12]
+}
+
+// secretFindings flattens scanner results into one finding per detected word.
+// Local results have no jsmon ID, so their IDs derive from the file path.
+func secretFindings(items []DataItem) []secretFinding {
+	var findings []secretFinding
+	seen := map[string]bool{}
+	for _, item := range items {
+		source := item.JsmonId
+		if source == "" {
+			source = item.URL
+		}
+		for _, detected := range item.DetectedWords {
+			for _, word := range detected.Words {
+				id := findingID(source, detected.Name, word)
+				if seen[id] {
+					continue
+				}
+				seen[id] = true
+				finding := secretFinding{
+					ID:        id,
+					JsmonId:   item.JsmonId,
+					URL:       item.URL,
+					Module:    detected.Name,
+					Word:      word,
+					CreatedAt: item.CreatedAt,
+					Status:    statusNew,
+				}
+				if v, ok := detected.verification(word); ok {
+					finding.Verdict, finding.Confidence = v.Verdict, v.Confidence
+				}
+				findings = append(findings, finding)
+			}
+		}
+	}
+	return findings
+}
+
+// triageEntry is the stored review state of a finding.
+type triageEntry struct {
+	Status    string `json:"status"`
+	Note      string `json:"note,omitempty"`
+	JsmonId   string `json:"jsmonId"`
+	Module    string `json:"module"`
+	Word      string `json:"word"`
+	UpdatedAt string `json:"updatedAt"`
+}
+
+// triageStore maps finding IDs to their review state. It is kept in
+// ~/.jsmon/triage.json.
+type triageStore map[string]triageEntry
+
+func loadTriageStore() (triageStore, error) {
+	path, err := configPath(triageFile)
+	if err != nil {
+		return nil, err
+	}
+	store := triageStore{}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return store, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading %s: %v", path, err)
+	}
+	if err := json.Unmarshal(data, &store); err != nil {
+		return nil, fmt.Errorf("error parsing %s: %v", path, err)
+	}
+	return store, nil
+}
+
+func (s triageStore) save() error {
+	path, err := configPath(triageFile)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error formatting JSON: %v", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0600); err != nil {
+		return fmt.Errorf("error writing %s: %v", path, err)
+	}
+	return nil
+}
+
+// apply fills in the stored status and note of each finding.
+func (s triageStore) apply(findings []secretFinding) {
+	for i := range findings {
+		if entry, ok := s[findings[i].ID]; ok {
+			findings[i].Status = entry.Status
+			findings[i].Note = entry.Note
+		}
+	}
+}
+
+// mark records a status for a finding. Marking a finding new removes it from
+// the store.
+func (s triageStore) mark(finding secretFinding, status, note string) {
+	if status == statusNew {
+		delete(s, finding.ID)
+		return
+	}
+	s[finding.ID] = triageEntry{
+		Status:    status,
+		Note:      note,
+		JsmonId:   finding.JsmonId,
+		Module:    finding.Module,
+		Word:      finding.Word,
+		UpdatedAt: time.Now().UTC().Format(time.RFC3339),
+	}
+}
+
+func parseTriageStatus(status string) (string, error) {
+	status = strings.ToLower(strings.TrimSpace(status))
+	for _, valid := range triageStatuses {
+		if status == valid {
+			return status, nil
+		}
+	}
+	return "", fmt.Errorf("invalid status %q%s, valid statuses: %s", status, didYouMean(status, triageStatuses), strings.Join(triageStatuses, ", "))
+}
+
+// filterByStatus keeps the findings with the given status; "all" keeps
+// everything.
+func filterByStatus(findings []secretFinding, status string) []secretFinding {
+	if status == "all" {
+		return findings
+	}
+	var filtered []secretFinding
+	for _, finding := range findings {
+		if finding.Status == status {
+			filtered = append(filtered, finding)
+		}
+	}
+	return filtered
+}
+
+func sortFindings(findings []secretFinding) {
+	sort.SliceStable(findings, func(i, j int) bool {
+		a, b := findings[i], findings[j]
+		if a.Module != b.Module {
+			return a.Module < b.Module
+		}
+		if a.URL != b.URL {
+			return a.URL < b.URL
+		}
+		return a.Word < b.Word
+	})
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+// diffLine is one line of a diff: ' ' for context, '+' for added and '-' for
+// removed lines.
+type diffLine struct {
+	Op   byte
+	Text string
+}
+
+type diffHunk struct {
+	OldStart, OldLines int
+	NewStart, NewLines int
+	Lines              []diffLine
+}
+
+// diffLinesFromItems splits the multi-line chunks returned by the compare API
+// into individual diff lines.
+func diffLinesFromItems(items []DiffItem) []diffLine {
+	var lines []diffLine
+	for _, item := range items {
+		if item.Value == "" {
+			continue
+		}
+		op := byte(' ')
+		if item.Added {
+			op = '+'
+		} else if item.Removed {
+			op = '-'
+		}
+		for _, text := range strings.Split(strings.TrimSuffix(item.Value, "\n"), "\n") {
+			lines = append(lines, diffLine{Op: op, Text: text})
+		}
+	}
+	return lines
+}
+
+// buildHunks groups changed lines into hunks with up to context unchanged
+// lines around them. Changes separated by at most 2*context unchanged lines
+// share a hunk.
+func buildHunks(lines []diffLine, context int) []diffHunk {
+	if context < 0 {
+		context = 0
+	}
+
+	// oldBefore[i] and newBefore[i] count the old and new lines before line i.
+	oldBefore := make([]int, len(lines)+1)
+	newBefore := make([]int, len(lines)+1)
+	for i, line := range lines {
+		oldBefore[i+1] = oldBefore[i]
+		newBefore[i+1] = newBefore[i]
+		if line.Op != '+' {
+			oldBefore[i+1]++
+		}
+		if line.Op != '-' {
+			newBefore[i+1]++
+		}
+	}
+
+	var hunks []diffHunk
+	for i := 0; i < len(lines); i++ {
+		if lines[i].Op == ' ' {
+			continue
+		}
+		start := i - context
+		if start < 0 {
+			start = 0
+		}
+		end := i + 1
+		for j := i + 1; j < len(lines); j++ {
+			if lines[j].Op == ' ' {
+				continue
+			}
+			if j-end > 2*context {
+				break
+			}
+			end = j + 1
+		}
+		i = end - 1
+		end += context
+		if end > len(lines) {
+			end = len(lines)
+		}
+
+		hunk := diffHunk{
+			OldLines: oldBefore[end] - oldBefore[start],
+			NewLines: newBefore[end] - newBefore[start],
+			Lines:    lines[start:end],
+		}
+		hunk.OldStart = oldBefore[start]
+		if hunk.OldLines > 0 {
+			hunk.OldStart++
+		}
+		hunk.NewStart = newBefore[start]
+		if hunk.NewLines > 0 {
+			hunk.NewStart++
+		}
+		hunks = append(hunks, hunk)
+	}
+	return hunks
+}
+
+// diffStat counts added and removed lines.
+func diffStat(lines []diffLine) (added, removed int) {
+	for _, line := range lines {
+		switch line.Op {
+		case '+':
+			added++
+		case '-':
+			removed++
+		}
+	}
+	return added, removed
+}
+
+func formatHunkRange(start, count int) string {
+	if count == 1 {
+		return fmt.Sprintf("%d", start)
+	}
+	return fmt.Sprintf("%d,%d", start, count)
+}
+
+// writeUnifiedDiff writes hunks in unified diff format, colorized when
+// colorize is set.
+func writeUnifiedDiff(w io.Writer, oldName, newName string, hunks []diffHunk, colorize bool) {
+	header := color.New(color.Bold)
+	hunkHeader := color.New(color.FgCyan)
+	added := color.New(color.FgGreen)
+	removed := color.New(color.FgRed)
+	for _, c := range []*color.Color{header, hunkHeader, added, removed} {
+		if colorize {
+			c.EnableColor()
+		} else {
+			c.DisableColor()
+		}
+	}
+
+	header.Fprintf(w, "--- a/%s\n", oldName)
+	header.Fprintf(w, "+++ b/%s\n", newName)
+	for _, hunk := range hunks {
+		hunkHeader.Fprintf(w, "@@ -%s +%s @@\n", formatHunkRange(hunk.OldStart, hunk.OldLines), formatHunkRange(hunk.NewStart, hunk.NewLines))
+		for _, line := range hunk.Lines {
+			switch line.Op {
+			case '+':
+				added.Fprintf(w, "+%s\n", line.Text)
+			case '-':
+				removed.Fprintf(w, "-%s\n", line.Text)
+			default:
+				fmt.Fprintf(w, " %s\n", line.Text)
+			}
+		}
+	}
+}
+
+// writeDiffStat writes a git-style summary of a diff.
+func writeDiffStat(w io.Writer, name string, lines []diffLine, hunks []diffHunk, colorize bool) {
+	addedCount, removedCount := diffStat(lines)
+	added := color.New(color.FgGreen)
+	removed := color.New(color.FgRed)
+	for _, c := range []*color.Color{added, removed} {
+		if colorize {
+			c.EnableColor()
+		} else {
+			c.DisableColor()
+		}
+	}
+
+	const barWidth = 50
+	plus, minus := addedCount, removedCount
+	if total := addedCount + removedCount; total > barWidth {
+		plus = addedCount * barWidth / total
+		minus = removedCount * barWidth / total
+	}
+	fmt.Fprintf(w, " %s | %d %s%s\n", name, addedCount+removedCount,
+		added.Sprint(strings.Repeat("+", plus)), removed.Sprint(strings.Repeat("-", minus)))
+	fmt.Fprintf(w, " %d hunks, %d insertions(+), %d deletions(-)\n", len(hunks), addedCount, removedCount)
+}
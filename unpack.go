@@ -0,0 +1,410 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+var (
+	// regionPattern matches the //#region <path> markers Rolldown-based Vite
+	// and Rollup plugins put around each module.
+	regionPattern = regexp.MustCompile(`(?m)^[ \t]*//#(region|endregion)[ \t]*(.*?)[ \t]*$`)
+	// esbuildModulePattern matches the // <path> comment esbuild puts before
+	// each module in unminified output.
+	esbuildModulePattern = regexp.MustCompile(`(?m)^[ \t]*// ((?:[\w@.+\-]+/)*[\w@.+\-]+\.(?:[cm]?[jt]sx?|vue|svelte|json|css))[ \t]*$`)
+	sourceURLPattern     = regexp.MustCompile(`//[#@] sourceURL=(\S+)`)
+	unsafeModuleIDChars  = regexp.MustCompile(`[^A-Za-z0-9._\-]`)
+)
+
+// bundleModule is one module split out of a bundle.
+type bundleModule struct {
+	ID     string `json:"id"`
+	Path   string `json:"path,omitempty"`
+	File   string `json:"file"`
+	Source string `json:"-"`
+}
+
+// bundleManifest describes an unpacked bundle. It is written next to the
+// modules as manifest.json.
+type bundleManifest struct {
+	Input   string         `json:"input"`
+	Format  string         `json:"format"`
+	Modules []bundleModule `json:"modules"`
+}
+
+// matchingBracket returns the index of the token that closes the bracket
+// opened at tokens[open], or -1 if it is never closed.
+func matchingBracket(tokens []jsToken, open int) int {
+	depth := 0
+	for i := open; i < len(tokens); i++ {
+		if tokens[i].Kind != jsPunct {
+			continue
+		}
+		switch tokens[i].Value {
+		case "(", "[", "{":
+			depth++
+		case ")", "]", "}":
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+func isPunct(tokens []jsToken, i int, value string) bool {
+	return i >= 0 && i < len(tokens) && tokens[i].Kind == jsPunct && tokens[i].Value == value
+}
+
+// functionEnd returns the index just past the function expression starting at
+// tokens[i], or -1 if none starts there. It accepts function expressions,
+// arrow functions with a block body and either of them wrapped in parentheses.
+func functionEnd(tokens []jsToken, i int) int {
+	if i >= len(tokens) {
+		return -1
+	}
+	blockEnd := func(open int) int {
+		if !isPunct(tokens, open, "{") {
+			return -1
+		}
+		if end := matchingBracket(tokens, open); end >= 0 {
+			return end + 1
+		}
+		return -1
+	}
+
+	t := tokens[i]
+	switch {
+	case t.Kind == jsIdent && t.Value == "function":
+		j := i + 1
+		for j < len(tokens) && !isPunct(tokens, j, "(") {
+			j++
+		}
+		params := matchingBracket(tokens, j)
+		if params < 0 {
+			return -1
+		}
+		return blockEnd(params + 1)
+	case t.Kind == jsIdent && isPunct(tokens, i+1, "=>"):
+		return blockEnd(i + 2)
+	case isPunct(tokens, i, "("):
+		closing := matchingBracket(tokens, i)
+		if closing < 0 {
+			return -1
+		}
+		if isPunct(tokens, closing+1, "=>") {
+			return blockEnd(closing + 2)
+		}
+		if functionEnd(tokens, i+1) == closing {
+			return closing + 1
+		}
+	}
+	return -1
+}
+
+// evalModuleSource returns the code of a module built with webpack's eval
+// devtool, whose body is a single eval("...") call, and "" for other modules.
+func evalModuleSource(tokens []jsToken) string {
+	open := 0
+	for open < len(tokens) && !(isPunct(tokens, open, "{") && (isPunct(tokens, open-1, ")") || isPunct(tokens, open-1, "=>"))) {
+		open++
+	}
+	closing := matchingBracket(tokens, open)
+	if open == len(tokens) || closing < 0 {
+		return ""
+	}
+	body := tokens[open+1 : closing]
+	if len(body) > 0 && body[0].Kind == jsString && body[0].Text == "use strict" {
+		body = body[1:]
+		if isPunct(body, 0, ";") {
+			body = body[1:]
+		}
+	}
+	if len(body) >= 4 && body[0].Kind == jsIdent && body[0].Value == "eval" && isPunct(body, 1, "(") &&
+		body[2].Kind == jsString && isPunct(body, 3, ")") && (len(body) == 4 || len(body) == 5 && isPunct(body, 4, ";")) {
+		return body[2].Text
+	}
+	return ""
+}
+
+// newBundleModule builds a module from its ID, or path if the ID is one, and
+// its source. The file it is written to always has a JS extension so that
+// analyze picks it up.
+func newBundleModule(id, source string) bundleModule {
+	module := bundleModule{ID: id, Source: source}
+	if strings.Contains(id, "/") {
+		module.Path = id
+	} else if match := sourceURLPattern.FindStringSubmatch(source); match != nil {
+		module.Path = match[1]
+	}
+	if module.Path != "" {
+		module.File = safeSourcePath(module.Path)
+	}
+	if module.File == "" {
+		module.File = "modules/" + unsafeModuleIDChars.ReplaceAllString(id, "_")
+	}
+	if !jsFileExtensions[strings.ToLower(path.Ext(module.File))] {
+		module.File += ".js"
+	}
+	return module
+}
+
+func webpackModule(id string, tokens []jsToken) bundleModule {
+	if src := evalModuleSource(tokens); src != "" {
+		return newBundleModule(id, beautifyJS(src))
+	}
+	return newBundleModule(id, formatJSTokens(tokens))
+}
+
+// hasWebpackMarker reports whether tokens come from a webpack bundle: they
+// use __webpack_modules__ or __webpack_require__, push to a webpackChunk or
+// webpackJsonp array, or call a bootstrap function with an array or object
+// of modules, as in "}([" and "})({".
+func hasWebpackMarker(tokens []jsToken) bool {
+	chunkArray := false
+	for i, t := range tokens {
+		if t.Kind == jsIdent {
+			switch {
+			case t.Value == "__webpack_modules__" || t.Value == "__webpack_require__":
+				return true
+			case strings.HasPrefix(t.Value, "webpackChunk") || strings.HasPrefix(t.Value, "webpackJsonp"):
+				chunkArray = true
+			case chunkArray && t.Value == "push" && isPunct(tokens, i-1, ".") && isPunct(tokens, i+1, "("):
+				return true
+			}
+			continue
+		}
+		if isPunct(tokens, i, "(") && (isPunct(tokens, i+1, "[") || isPunct(tokens, i+1, "{")) &&
+			(isPunct(tokens, i-1, "}") || isPunct(tokens, i-1, ")") && isPunct(tokens, i-2, "}")) {
+			return true
+		}
+	}
+	return false
+}
+
+// splitWebpackModules finds the module maps of a webpack bundle or chunk:
+// objects from numeric or string module IDs to functions, as in
+// __webpack_modules__ and webpackChunk push calls, and the arrays of
+// functions webpack 4 passes to its bootstrap, where the ID is the index.
+// Without a webpack marker nothing is split, so an ordinary object of
+// functions such as event handlers is not taken for a module map.
+func splitWebpackModules(tokens []jsToken) []bundleModule {
+	if !hasWebpackMarker(tokens) {
+		return nil
+	}
+	var modules []bundleModule
+	for i := 0; i < len(tokens); i++ {
+		t := tokens[i]
+		if (t.Kind == jsNumber || t.Kind == jsString) && (isPunct(tokens, i-1, "{") || isPunct(tokens, i-1, ",")) && isPunct(tokens, i+1, ":") {
+			if end := functionEnd(tokens, i+2); end > 0 {
+				id := t.Value
+				if t.Kind == jsString {
+					id = t.Text
+				}
+				modules = append(modules, webpackModule(id, tokens[i+2:end]))
+				i = end - 1
+			}
+			continue
+		}
+		if isPunct(tokens, i, "[") && (isPunct(tokens, i-1, "(") || isPunct(tokens, i-1, ",")) {
+			if found, end := splitWebpackArray(tokens, i); found != nil {
+				modules = append(modules, found...)
+				i = end
+			}
+		}
+	}
+	return modules
+}
+
+// splitWebpackArray returns the modules of the array opened at tokens[open]
+// and the index of its closing bracket, or nil if the array holds anything but
+// functions and holes.
+func splitWebpackArray(tokens []jsToken, open int) ([]bundleModule, int) {
+	var modules []bundleModule
+	index := 0
+	for i := open + 1; i < len(tokens); {
+		switch {
+		case isPunct(tokens, i, "]"):
+			return modules, i
+		case isPunct(tokens, i, ","):
+			index++
+			i++
+			continue
+		}
+		end := functionEnd(tokens, i)
+		if end < 0 {
+			return nil, open
+		}
+		modules = append(modules, webpackModule(fmt.Sprint(index), tokens[i:end]))
+		i = end
+		if !isPunct(tokens, i, ",") && !isPunct(tokens, i, "]") {
+			return nil, open
+		}
+	}
+	return nil, open
+}
+
+// splitRegionModules splits a bundle at //#region markers, and failing that
+// at esbuild's // <path> comments. Code outside any module is dropped.
+func splitRegionModules(src string) ([]bundleModule, string) {
+	var modules []bundleModule
+	start, name := -1, ""
+	for _, loc := range regionPattern.FindAllStringSubmatchIndex(src, -1) {
+		if src[loc[2]:loc[3]] == "region" {
+			start, name = loc[1], src[loc[4]:loc[5]]
+			continue
+		}
+		if start >= 0 && name != "" {
+			modules = append(modules, newBundleModule(name, beautifyJS(src[start:loc[0]])))
+		}
+		start = -1
+	}
+	if len(modules) > 0 {
+		return modules, "rollup"
+	}
+
+	locs := esbuildModulePattern.FindAllStringSubmatchIndex(src, -1)
+	for i, loc := range locs {
+		end := len(src)
+		if i+1 < len(locs) {
+			end = locs[i+1][0]
+		}
+		modules = append(modules, newBundleModule(src[loc[2]:loc[3]], beautifyJS(src[loc[1]:end])))
+	}
+	if len(modules) > 0 {
+		return modules, "esbuild"
+	}
+	return nil, ""
+}
+
+// unpackBundle splits a bundle into its modules. A bundle that is not split
+// comes back as a single beautified module named after the input, with
+// format "none".
+func unpackBundle(input, src string) bundleManifest {
+	manifest := bundleManifest{Input: input}
+	var tokens []jsToken
+	for _, t := range tokenizeJS(src) {
+		if t.Kind != jsComment {
+			tokens = append(tokens, t)
+		}
+	}
+	if modules := splitWebpackModules(tokens); len(modules) > 0 {
+		manifest.Format, manifest.Modules = "webpack", modules
+		return manifest
+	}
+	if modules, format := splitRegionModules(src); len(modules) > 0 {
+		manifest.Format, manifest.Modules = format, modules
+		return manifest
+	}
+
+	name := path.Base(safeSourcePath(input))
+	if name == "." {
+		name = "bundle.js"
+	}
+	file := name
+	if !jsFileExtensions[strings.ToLower(path.Ext(file))] {
+		file += ".js"
+	}
+	module := bundleModule{ID: name, File: file, Source: beautifyJS(src)}
+	manifest.Format, manifest.Modules = "none", []bundleModule{module}
+	return manifest
+}
+
+// writeUnpackedBundle writes the modules of manifest and manifest.json under
+// dir. The files in the manifest are updated to the paths written.
+func writeUnpackedBundle(dir string, manifest *bundleManifest) error {
+	sources := make([]sourceMapSource, len(manifest.Modules))
+	for i, module := range manifest.Modules {
+		sources[i] = sourceMapSource{Name: module.File, Content: module.Source}
+	}
+	written, err := writeSourceTree(dir, sources)
+	if err != nil {
+		return err
+	}
+	root, err := filepath.Abs(dir)
+	if err != nil {
+		return fmt.Errorf("error resolving %s: %v", dir, err)
+	}
+	for i, target := range written {
+		if rel, err := filepath.Rel(root, target); err == nil {
+			manifest.Modules[i].File = filepath.ToSlash(rel)
+		}
+	}
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error formatting JSON: %v", err)
+	}
+	target := filepath.Join(dir, "manifest.json")
+	if err := os.WriteFile(target, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("error writing %s: %v", target, err)
+	}
+	return nil
+}
+
+func runUnpackCommand(args []string) error {
+	fs := newCommandFlagSet("unpack", "unpack <bundle.js|url>... [-o dir] [-p] [-analyze]")
+	outDir := fs.String("o", "unpacked", "Directory to write the modules into")
+	printOnly := fs.Bool("p", false, "Print the beautified JS instead of splitting it")
+	analyze := fs.Bool("analyze", false, "Run the local analysis on the unpacked modules")
+	var headerFlags stringSliceFlag
+	fs.Var(&headerFlags, "H", "Header for fetching bundles in the format 'Key: Value' (can be used multiple times)")
+	positional, err := parseCommandArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) == 0 {
+		fs.Usage()
+		return fmt.Errorf("unpack needs a JS file or URL")
+	}
+
+	fetcher := newJSFetcher(append(append([]string{}, headers...), headerFlags...))
+	var dirs []string
+	failed := 0
+	for _, input := range positional {
+		resource, err := fetcher.fetch(input)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "[ERR] %s: %v\n", input, err)
+			failed++
+			continue
+		}
+		if *printOnly {
+			fmt.Print(beautifyJS(string(resource.Body)))
+			continue
+		}
+
+		manifest := unpackBundle(input, string(resource.Body))
+		dir := sourceMapOutputDir(*outDir, input, len(positional) > 1)
+		if err := writeUnpackedBundle(dir, &manifest); err != nil {
+			fmt.Fprintf(os.Stderr, "[ERR] %s: %v\n", input, err)
+			failed++
+			continue
+		}
+		if manifest.Format == "none" {
+			fmt.Printf("[INF] %s: no bundle modules found, wrote the beautified file to %s\n", input, dir)
+		} else {
+			fmt.Printf("[INF] %s: %s bundle, wrote %d modules to %s\n", input, manifest.Format, len(manifest.Modules), dir)
+		}
+		dirs = append(dirs, dir)
+	}
+
+	if *analyze && len(dirs) > 0 {
+		results, err := analyzeLocalFiles(dirs)
+		if err != nil {
+			return err
+		}
+		if err := printIntelligenceResults(results, nil); err != nil {
+			return err
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d inputs failed", failed, len(positional))
+	}
+	return nil
+}
@@ -79,7 +79,7 @@ func uploadUrlEndpoint(url string, customHeaders []string, wkspId string) error
 
 	// Check for jsmonId or fileId to determine if we need to get automation results
 	if jsmonID, ok := response["jsmonId"].(string); ok && jsmonID != "" {
-		getAutomationResultsByJsmonId(jsmonID, wkspId)
+		return getAutomationResultsByJsmonId(jsmonID, wkspId, nil)
 	} else if fileID, ok := response["fileId"].(string); ok && fileID != "" {
 		// You can add handling for fileId here if needed
 		fmt.Printf("File ID received: %s\n", fileID)
@@ -0,0 +1,237 @@
+package main
+
+import (
+	"hash/crc32"
+	"math"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Verification verdicts.
+const (
+	verdictValid       = "valid"
+	verdictInvalid     = "invalid"
+	verdictExpired     = "expired"
+	verdictPlaceholder = "placeholder"
+	verdictUnverified  = "unverified"
+)
+
+// wordVerification is the result of checking one detected word offline. No
+// request is made to the service the credential belongs to.
+type wordVerification struct {
+	Word       string   `json:"word"`
+	Format     string   `json:"format,omitempty"`
+	Verdict    string   `json:"verdict"`
+	Confidence float64  `json:"confidence"`
+	Entropy    float64  `json:"entropy"`
+	Notes      []string `json:"notes,omitempty"`
+}
+
+// tokenFormat is a credential format with a known shape. check, if set, runs
+// further validation such as a checksum and returns a verdict and note.
+type tokenFormat struct {
+	Name    string
+	Pattern *regexp.Regexp
+	Check   func(word string) (string, string)
+}
+
+var tokenFormats = []tokenFormat{
+	{"github", regexp.MustCompile(`^(ghp|gho|ghu|ghs|ghr)_[0-9A-Za-z]{36}$`), checkCRC32Token},
+	{"github-fine-grained", regexp.MustCompile(`^github_pat_[0-9A-Za-z_]{82}$`), nil},
+	{"npm", regexp.MustCompile(`^npm_[0-9A-Za-z]{36}$`), checkCRC32Token},
+	{"aws-access-key", regexp.MustCompile(`^(AKIA|ASIA|AGPA|AIDA|AROA|ANPA|ANVA|AIPA)[A-Z2-7]{16}$`), nil},
+	{"google-api-key", regexp.MustCompile(`^AIza[0-9A-Za-z_\-]{35}$`), nil},
+	{"slack", regexp.MustCompile(`^xox[abposr]-[0-9A-Za-z\-]{10,}$`), nil},
+	{"stripe", regexp.MustCompile(`^(sk|rk|pk)_(live|test)_[0-9A-Za-z]{16,}$`), checkStripeKey},
+	{"jwt", regexp.MustCompile(`^eyJ[A-Za-z0-9_\-]+\.eyJ[A-Za-z0-9_\-]+\.[A-Za-z0-9_\-]*$`), checkJWT},
+}
+
+// placeholderPatterns match values that are documentation or template
+// placeholders rather than real credentials.
+var placeholderPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`(?i)x{4,}`),
+	regexp.MustCompile(`(?i)^\*+$`),
+	regexp.MustCompile(`(?i)your[_\-\s]?\w*[_\-\s]?(key|token|secret|password|id)(_here)?`),
+	regexp.MustCompile(`(?i)(_here|changeme|change_me|placeholder|dummy|example|sample|redacted|insert|replace)`),
+	regexp.MustCompile(`^(<[^>]*>|\$\{[^}]*\}|\{\{[^}]*\}\}|%[A-Za-z_]+%)$`),
+	regexp.MustCompile(`(?i)(0123456789|1234567890|abcdefgh)`),
+}
+
+const base62Alphabet = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+func base62Encode(n uint32, width int) string {
+	var out []byte
+	for n > 0 {
+		out = append([]byte{base62Alphabet[n%62]}, out...)
+		n /= 62
+	}
+	for len(out) < width {
+		out = append([]byte{'0'}, out...)
+	}
+	return string(out)
+}
+
+// checkCRC32Token validates the checksum of GitHub and npm tokens: the last 6
+// characters are the base62 CRC32 of the 30 random characters before them.
+func checkCRC32Token(word string) (string, string) {
+	body := word[strings.Index(word, "_")+1:]
+	random, checksum := body[:30], body[30:]
+	if base62Encode(crc32.ChecksumIEEE([]byte(random)), 6) != checksum {
+		return verdictInvalid, "checksum mismatch"
+	}
+	return verdictValid, "checksum ok"
+}
+
+func checkStripeKey(word string) (string, string) {
+	if strings.Contains(word, "_test_") {
+		return verdictValid, "test mode key"
+	}
+	return verdictValid, ""
+}
+
+func checkJWT(word string) (string, string) {
+	token, err := decodeJWT(word)
+	if err != nil {
+		return verdictInvalid, "cannot decode: " + err.Error()
+	}
+	if strings.EqualFold(token.alg(), "none") {
+		return verdictValid, "alg none"
+	}
+	if exp, ok := token.timeClaim("exp"); ok && exp.Before(time.Now()) {
+		return verdictExpired, "expired " + exp.Format("2006-01-02")
+	}
+	return verdictValid, ""
+}
+
+// shannonEntropy returns the entropy of s in bits per character.
+func shannonEntropy(s string) float64 {
+	if s == "" {
+		return 0
+	}
+	counts := map[rune]int{}
+	for _, r := range s {
+		counts[r]++
+	}
+	length := float64(len([]rune(s)))
+	entropy := 0.0
+	for _, count := range counts {
+		p := float64(count) / length
+		entropy -= p * math.Log2(p)
+	}
+	return entropy
+}
+
+// isPlaceholder reports whether word matches a placeholder pattern or repeats
+// one character 8 or more times in a row.
+func isPlaceholder(word string) bool {
+	run := 0
+	for i := range word {
+		if i > 0 && word[i] == word[i-1] {
+			run++
+		} else {
+			run = 1
+		}
+		if run >= 8 {
+			return true
+		}
+	}
+	for _, pattern := range placeholderPatterns {
+		if pattern.MatchString(word) {
+			return true
+		}
+	}
+	return false
+}
+
+func roundScore(score float64) float64 {
+	return math.Round(score*100) / 100
+}
+
+// verifyWord classifies a detected word by format, checksum, entropy and
+// placeholder checks and gives it a confidence between 0 and 1 that it is a
+// real credential.
+func verifyWord(word string) wordVerification {
+	v := wordVerification{Word: word, Verdict: verdictUnverified, Entropy: roundScore(shannonEntropy(word))}
+	if isPlaceholder(word) {
+		v.Verdict, v.Confidence = verdictPlaceholder, 0.05
+		return v
+	}
+
+	// Entropy alone says little for short values, and random base64 tops out
+	// around 6 bits per character.
+	entropyScore := math.Min(v.Entropy/4.5, 1)
+	for _, format := range tokenFormats {
+		if !format.Pattern.MatchString(word) {
+			continue
+		}
+		v.Format, v.Verdict = format.Name, verdictValid
+		note := ""
+		if format.Check != nil {
+			v.Verdict, note = format.Check(word)
+		}
+		if note != "" {
+			v.Notes = append(v.Notes, note)
+		}
+		switch {
+		case v.Verdict == verdictInvalid:
+			v.Confidence = 0.1
+		case v.Verdict == verdictExpired:
+			v.Confidence = 0.3
+		case note == "checksum ok":
+			v.Confidence = 0.95
+		case note == "test mode key":
+			v.Confidence = 0.4
+		default:
+			v.Confidence = roundScore(0.6 + 0.3*entropyScore)
+		}
+		return v
+	}
+
+	v.Confidence = roundScore(0.7 * entropyScore)
+	if len(word) < 16 {
+		v.Confidence = roundScore(v.Confidence / 2)
+		v.Notes = append(v.Notes, "short value")
+	}
+	if v.Entropy < 3 {
+		v.Notes = append(v.Notes, "low entropy")
+	}
+	return v
+}
+
+// verifyDataItems attaches a verification of every detected word to the
+// scanner results.
+func verifyDataItems(items []DataItem) {
+	for i := range items {
+		for j := range items[i].DetectedWords {
+			detected := &items[i].DetectedWords[j]
+			detected.Verifications = make([]wordVerification, len(detected.Words))
+			for k, word := range detected.Words {
+				detected.Verifications[k] = verifyWord(word)
+			}
+		}
+	}
+}
+
+// verification returns the verification of word, if the words were verified.
+func (d DetectedWord) verification(word string) (wordVerification, bool) {
+	for _, v := range d.Verifications {
+		if v.Word == word {
+			return v, true
+		}
+	}
+	return wordVerification{}, false
+}
+
+// withMinConfidence returns d with only the verified words scoring at least
+// min.
+func (d DetectedWord) withMinConfidence(min float64) DetectedWord {
+	kept := DetectedWord{Name: d.Name}
+	for _, v := range d.Verifications {
+		if v.Confidence >= min {
+			kept.Words = append(kept.Words, v.Word)
+			kept.Verifications = append(kept.Verifications, v)
+		}
+	}
+	return kept
+}
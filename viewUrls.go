@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"os"
+	"reflect"
 	"strings"
 )
 
@@ -17,44 +19,63 @@ type URLItem struct {
 	URL string `json:"url"`
 }
 
-func viewUrls(size int, wkspId string) error {
-	endpoint := fmt.Sprintf("%s/searchAllUrls?size=%d&start=0&wkspId=%s", apiBaseURL, size, wkspId) // Use the size parameter
+func fetchUrlsPage(size, start int, wkspId string) ([]URLItem, error) {
+	endpoint := fmt.Sprintf("%s/searchAllUrls?size=%d&start=%d&wkspId=%s", apiBaseURL, size, start, wkspId)
 	client := &http.Client{}
 	req, err := http.NewRequest("GET", endpoint, nil)
 	if err != nil {
-		fmt.Println("Error creating request:", err)
-
+		return nil, fmt.Errorf("error creating request: %v", err)
 	}
 
 	req.Header.Set("X-Jsmon-Key", strings.TrimSpace(getAPIKey()))
 
 	resp, err := client.Do(req)
 	if err != nil {
-		fmt.Printf("failed to send request: %v", err)
-
+		return nil, fmt.Errorf("failed to send request: %v", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode == http.StatusUnauthorized {
-		fmt.Println("[ERR] Wrong API key")
-		return nil
+		return nil, fmt.Errorf("wrong API key")
 	}
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		fmt.Printf("failed to read response body: %v", err)
-
+		return nil, fmt.Errorf("failed to read response body: %v", err)
 	}
 	var response URLResponse
 	err = json.Unmarshal(body, &response)
 	if err != nil {
-		fmt.Printf("failed to unmarshal JSON response: %v", err)
-
+		return nil, fmt.Errorf("failed to unmarshal JSON response: %v", err)
 	}
+	return response.Urls, nil
+}
 
-	for _, urlItem := range response.Urls {
-		fmt.Println(urlItem.URL)
-	}
+// viewUrls prints size URLs from offset start. With all it keeps requesting
+// the following pages until the server returns an empty one, or one identical
+// to the previous page because it ignored the offset.
+func viewUrls(size, start int, all bool, wkspId string) error {
+	var previous []URLItem
+	for {
+		urls, err := fetchUrlsPage(size, start, wkspId)
+		if err != nil {
+			return err
+		}
+		if len(urls) == 0 {
+			return nil
+		}
+		if reflect.DeepEqual(urls, previous) {
+			fmt.Fprintf(os.Stderr, "[WRN] Offset %d repeats the previous page, stopping\n", start)
+			return nil
+		}
+		previous = urls
 
-	return nil
+		for _, urlItem := range urls {
+			fmt.Println(urlItem.URL)
+		}
+		if !all {
+			return nil
+		}
+		start += len(urls)
+	}
 }
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// decodeConfigFile reads a .json, .yaml or .yml file into v. Unknown fields are
+// rejected so typos in configuration files are reported.
+func decodeConfigFile(path string, v interface{}) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("error reading %s: %v", path, err)
+	}
+	return decodeConfigData(path, data, v)
+}
+
+// decodeConfigData decodes configuration data like decodeConfigFile, taking
+// the format from the extension of path.
+//
+// YAML is parsed by gopkg.in/yaml.v3, so anchors, aliases, block scalars and
+// flow collections all work, and then decoded through the JSON field names of
+// v. Mapping keys must therefore be strings, and only the first document of a
+// multi-document file is read.
+func decodeConfigData(path string, data []byte, v interface{}) error {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		var value interface{}
+		if err := yaml.Unmarshal(data, &value); err != nil {
+			return fmt.Errorf("error parsing %s: %v", path, err)
+		}
+		var err error
+		if data, err = json.Marshal(value); err != nil {
+			return fmt.Errorf("error converting %s: %v", path, err)
+		}
+	case ".json":
+	default:
+		return fmt.Errorf("unsupported file type %s, use .json, .yaml or .yml", path)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		return fmt.Errorf("error parsing %s: %v", path, err)
+	}
+	return nil
+}
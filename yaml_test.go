@@ -0,0 +1,111 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type testConfig struct {
+	Name    string            `json:"name"`
+	Count   int               `json:"count"`
+	Enabled bool              `json:"enabled"`
+	Tags    []string          `json:"tags"`
+	Labels  map[string]string `json:"labels"`
+	Text    string            `json:"text"`
+}
+
+func TestDecodeConfigData(t *testing.T) {
+	tests := []struct {
+		name string
+		path string
+		data string
+		want testConfig
+	}{
+		{
+			name: "json",
+			path: "config.json",
+			data: `{"name": "a", "count": 2, "tags": ["x", "y"]}`,
+			want: testConfig{Name: "a", Count: 2, Tags: []string{"x", "y"}},
+		},
+		{
+			name: "yaml block collections",
+			path: "config.yaml",
+			data: "name: a # comment\ncount: 2\nenabled: true\ntags:\n  - x\n  - y\nlabels:\n  env: prod\n",
+			want: testConfig{Name: "a", Count: 2, Enabled: true, Tags: []string{"x", "y"}, Labels: map[string]string{"env": "prod"}},
+		},
+		{
+			name: "yaml flow collections",
+			path: "config.yml",
+			data: "tags: [x, 'y z']\nlabels: {env: prod}\n",
+			want: testConfig{Tags: []string{"x", "y z"}, Labels: map[string]string{"env": "prod"}},
+		},
+		{
+			name: "yaml block scalars",
+			path: "config.yaml",
+			data: "name: >\n  folded\n  line\ntext: |\n  line 1\n  line 2\n",
+			want: testConfig{Name: "folded line\n", Text: "line 1\nline 2\n"},
+		},
+		{
+			name: "yaml anchors and aliases",
+			path: "config.yaml",
+			data: "labels: &labels {env: prod}\nname: &name shared\ntext: *name\n",
+			want: testConfig{Name: "shared", Text: "shared", Labels: map[string]string{"env": "prod"}},
+		},
+		{
+			name: "yaml tags",
+			path: "config.yaml",
+			data: "name: !!str 123\ncount: !!int \"7\"\n",
+			want: testConfig{Name: "123", Count: 7},
+		},
+		{
+			name: "yaml document marker",
+			path: "CONFIG.YAML",
+			data: "---\nname: a\n",
+			want: testConfig{Name: "a"},
+		},
+		{
+			name: "empty yaml",
+			path: "config.yaml",
+			data: "",
+			want: testConfig{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got testConfig
+			if err := decodeConfigData(tt.path, []byte(tt.data), &got); err != nil {
+				t.Fatalf("decodeConfigData: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeConfigDataErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		path    string
+		data    string
+		wantErr string
+	}{
+		{"unknown json field", "config.json", `{"nmae": "a"}`, "unknown field"},
+		{"unknown yaml field", "config.yaml", "nmae: a\n", "unknown field"},
+		{"wrong type", "config.yaml", "count: many\n", "cannot unmarshal"},
+		{"invalid yaml", "config.yaml", "tags: [a, b\n", "error parsing config.yaml"},
+		{"tab indentation", "config.yaml", "labels:\n\tenv: prod\n", "error parsing config.yaml"},
+		{"non-string keys", "config.yaml", "labels:\n  1: a\n  true: b\n", "error converting config.yaml"},
+		{"unsupported extension", "config.toml", "name = 'a'", "unsupported file type"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got testConfig
+			err := decodeConfigData(tt.path, []byte(tt.data), &got)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("got error %v, want one containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}